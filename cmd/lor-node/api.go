@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"golang.org/x/exp/maps"
+)
+
+type CoinRequest struct {
+	Amount float64 `json:"amount"`
+	Type   uint    `json:"type"`
+}
+
+type ProposalResponse struct {
+	Proposed bool             `json:"proposed"`
+	Accepted bool             `json:"accepted"`
+	Error    string           `json:"error,omitempty"`
+	Fractal  *pkg.FractalRing `json:"fractal,omitempty"`
+}
+
+type RingStatus struct {
+	pkg.CooperationTable
+	Coins []pkg.CoinTable `json:"coins"`
+}
+
+func (server *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST "+pkg.MessagePath, server.Transport)
+	mux.HandleFunc("GET /trader", server.getTrader)
+	mux.HandleFunc("POST /coins", server.postCoin)
+	mux.HandleFunc("POST /fractals", server.postFractal)
+	mux.HandleFunc("POST /fractals/verify", server.verifyFractal)
+	mux.HandleFunc("POST /rounds/vote", server.voteRound)
+	mux.HandleFunc("GET /balances", server.getBalances)
+	mux.HandleFunc("GET /ledger", server.getLedger)
+	mux.HandleFunc("GET /ledger/divergences", server.getDivergences)
+	mux.HandleFunc("GET /rings", server.getRings)
+	mux.HandleFunc("GET /rings/{id}", server.getRing)
+	return mux
+}
+
+func (server *Server) getTrader(w http.ResponseWriter, r *http.Request) {
+	server.locker.Lock()
+	trader := *server.Node.Trader
+	server.locker.Unlock()
+	writeJSON(w, http.StatusOK, trader)
+}
+
+func (server *Server) postCoin(w http.ResponseWriter, r *http.Request) {
+	var request CoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server.locker.Lock()
+	t := server.Node.Trader
+	var coin *pkg.CoinTable
+	if request.Amount > 0 && request.Type < t.Data.CoinTypeCount {
+		coin = t.CreateCoin(request.Amount, request.Type, time.Now().UnixMilli())
+	}
+	server.locker.Unlock()
+	if coin == nil {
+		http.Error(w, "coin creation failed", http.StatusBadRequest)
+		return
+	}
+
+	server.broadcast(pkg.Message{Kind: pkg.CoinMessage, Coin: coin})
+	writeJSON(w, http.StatusCreated, coin)
+}
+
+func (server *Server) postFractal(w http.ResponseWriter, r *http.Request) {
+	fractal, err := server.Propose()
+	response := ProposalResponse{Proposed: fractal != nil, Accepted: fractal != nil && err == nil, Fractal: fractal}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) verifyFractal(w http.ResponseWriter, r *http.Request) {
+	var request pkg.Message
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Fractal == nil {
+		http.Error(w, "request needs a fractal ring", http.StatusBadRequest)
+		return
+	}
+
+	server.locker.Lock()
+	defer server.locker.Unlock()
+	vote := pkg.Vote{FractalID: request.Fractal.ID, Round: pkg.VerificationRound}
+	server.writeVote(w, vote, server.Node.Trader.SubmitRing(request.Fractal))
+}
+
+func (server *Server) voteRound(w http.ResponseWriter, r *http.Request) {
+	var request pkg.Message
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Fractal == nil || request.Ring == nil {
+		http.Error(w, "request needs a fractal ring and a cooperation ring", http.StatusBadRequest)
+		return
+	}
+
+	server.locker.Lock()
+	defer server.locker.Unlock()
+	vote := pkg.Vote{FractalID: request.Fractal.ID, RingID: request.Ring.ID, Round: request.Fractal.Round}
+	server.writeVote(w, vote, server.Node.Trader.Vote(request.Fractal, *request.Ring))
+}
+
+func (server *Server) getBalances(w http.ResponseWriter, r *http.Request) {
+	server.locker.Lock()
+	balances := make(map[string]float64)
+	for traderID, trader := range server.Node.Trader.Data.Traders {
+		balances[traderID] = trader.Account
+	}
+	server.locker.Unlock()
+	writeJSON(w, http.StatusOK, balances)
+}
+
+func (server *Server) getLedger(w http.ResponseWriter, r *http.Request) {
+	server.locker.Lock()
+	ledger := server.Node.Trader.Data.Ledger.Clone()
+	server.locker.Unlock()
+	if ledger == nil {
+		http.Error(w, "ledger is disabled", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+func (server *Server) getDivergences(w http.ResponseWriter, r *http.Request) {
+	address := strings.TrimRight(r.URL.Query().Get("peer"), "/")
+	if !slices.Contains(maps.Values(server.Transport.Peers()), address) {
+		http.Error(w, "peer is not configured", http.StatusBadRequest)
+		return
+	}
+	peer, err := fetchLedger(server.Transport.Client, address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	server.locker.Lock()
+	ledger := server.Node.Trader.Data.Ledger.Clone()
+	server.locker.Unlock()
+	if ledger == nil {
+		http.Error(w, "ledger is disabled", http.StatusNotFound)
+		return
+	}
+	divergences := pkg.CompareLedgers(ledger, peer)
+	if divergences == nil {
+		divergences = []pkg.Divergence{}
+	}
+	writeJSON(w, http.StatusOK, divergences)
+}
+
+func (server *Server) getRings(w http.ResponseWriter, r *http.Request) {
+	server.locker.Lock()
+	t := server.Node.Trader
+	ringIDs := maps.Keys(t.Data.Cooperations)
+	slices.Sort(ringIDs)
+	rings := make([]RingStatus, 0, len(ringIDs))
+	for _, ringID := range ringIDs {
+		rings = append(rings, server.ringStatus(t.Data.Cooperations[ringID]))
+	}
+	server.locker.Unlock()
+	writeJSON(w, http.StatusOK, rings)
+}
+
+func (server *Server) getRing(w http.ResponseWriter, r *http.Request) {
+	server.locker.Lock()
+	ring, ok := server.Node.Trader.Data.Cooperations[r.PathValue("id")]
+	var status RingStatus
+	if ok {
+		status = server.ringStatus(ring)
+	}
+	server.locker.Unlock()
+	if !ok {
+		http.Error(w, "cooperation ring not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (server *Server) ringStatus(ring pkg.CooperationTable) RingStatus {
+	status := RingStatus{CooperationTable: ring, Coins: make([]pkg.CoinTable, 0, len(ring.CoinIDs))}
+	for _, coinID := range ring.CoinIDs {
+		status.Coins = append(status.Coins, server.Node.Trader.Data.Coins[coinID])
+	}
+	return status
+}
+
+func (server *Server) writeVote(w http.ResponseWriter, vote pkg.Vote, err error) {
+	vote.Accept = err == nil
+	if err != nil {
+		vote.Reason = err.Error()
+	}
+
+	votes := []pkg.Vote{vote}
+	if err := server.Node.Trader.SignVotes(votes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, votes[0])
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/internal"
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+	"github.com/google/uuid"
+)
+
+type Flags struct {
+	Listen      string
+	Peers       []string
+	Wallet      string
+	Account     float64
+	NumTypes    int
+	Behavior    pkg.BehaviorType
+	Seed        uint64
+	Params      pkg.Params
+	VoteTimeout time.Duration
+	JoinTimeout time.Duration
+}
+
+var behaviors = map[string]pkg.BehaviorType{
+	"normal": pkg.Normal,
+	"random": pkg.RandomVote,
+	"bad":    pkg.BadVote,
+}
+
+func ParseFlags() Flags {
+	listenPtr := flag.String("listen", "localhost:8000", "address to serve the node API on")
+	peersPtr := flag.String("peers", "", "comma separated base URLs of the other nodes (e.g. http://localhost:8001)")
+	walletPtr := flag.String("wallet", "", "wallet of the trader (random if empty)")
+	accountPtr := flag.Float64("account", 1000, "initial account of the trader")
+	typesPtr := flag.Int("type", 3, "number of coin types (must match all peers)")
+	behaviorPtr := flag.String("behavior", "normal", "behavior of the trader (normal, random or bad)")
+	seedPtr := flag.Uint64("seed", 0, "random seed of the trader (0 for a random seed)")
+	voteTimeoutPtr := flag.Duration("vote-timeout", 5*time.Second, "time to wait for the votes of a verification team")
+	joinTimeoutPtr := flag.Duration("join-timeout", time.Minute, "time to wait for all peers to come up")
+	parseParams := internal.BindParams(flag.CommandLine, true)
+	flag.Parse()
+
+	params, err := parseParams(pkg.DefaultParams())
+	if err != nil {
+		log.Fatalf("Invalid protocol parameters: %v\n", err)
+	}
+	behavior, ok := behaviors[*behaviorPtr]
+	if !ok {
+		log.Fatalf("Behavior must be normal, random or bad\n")
+	} else if *typesPtr < 1 {
+		log.Fatalf("Number of types must be positive\n")
+	} else if *accountPtr < 0 {
+		log.Fatalf("Account must be non-negative\n")
+	}
+
+	var peers []string
+	for _, peer := range strings.Split(*peersPtr, ",") {
+		if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer != "" {
+			peers = append(peers, peer)
+		}
+	}
+
+	return Flags{
+		Listen:      *listenPtr,
+		Peers:       peers,
+		Wallet:      *walletPtr,
+		Account:     *accountPtr,
+		NumTypes:    *typesPtr,
+		Behavior:    behavior,
+		Seed:        *seedPtr,
+		Params:      params,
+		VoteTimeout: *voteTimeoutPtr,
+		JoinTimeout: *joinTimeoutPtr,
+	}
+}
+
+func main() {
+	flags := ParseFlags()
+	trader, err := CreateTrader(flags)
+	if err != nil {
+		log.Fatalf("Error creating trader: %v\n", err)
+	}
+
+	server, err := NewServer(trader, flags.Params, flags.VoteTimeout)
+	if err != nil {
+		log.Fatalf("Error creating server: %v\n", err)
+	}
+	go func() {
+		log.Printf("Trader %s listening on %s\n", trader.ID, flags.Listen)
+		if err := http.ListenAndServe(flags.Listen, server.Routes()); err != nil {
+			log.Fatalf("Error serving API: %v\n", err)
+		}
+	}()
+
+	if err := server.Join(flags.Peers, flags.JoinTimeout); err != nil {
+		log.Fatalf("Error joining peers: %v\n", err)
+	}
+	log.Printf("Joined %d peers\n", len(flags.Peers))
+	if flags.Params.CoinTTL > 0 {
+		go server.RefundExpired(time.Second)
+	}
+	select {}
+}
+
+func CreateTrader(flags Flags) (*pkg.Trader, error) {
+	seed := flags.Seed
+	if seed == 0 {
+		var data [8]byte
+		if _, err := crand.Read(data[:]); err == nil {
+			seed = binary.LittleEndian.Uint64(data[:])
+		}
+	}
+	random := tools.NewRandom(seed)
+
+	var keyRandom io.Reader
+	if flags.Seed != 0 {
+		keyRandom = random
+	}
+	wallet := flags.Wallet
+	if wallet == "" {
+		id, err := uuid.NewRandomFromReader(random)
+		if err != nil {
+			return nil, err
+		}
+		wallet = id.String()
+	}
+
+	trader := pkg.CreateTrader(&flags.Params, flags.Behavior, flags.Account, wallet, uint(flags.NumTypes), random, keyRandom)
+	if trader == nil {
+		return nil, errors.New("trader creation failed")
+	}
+	return trader, nil
+}
+
+func fetchTrader(client *http.Client, address string) (*pkg.Trader, error) {
+	var trader pkg.Trader
+	if err := fetchJSON(client, address+"/trader", &trader); err != nil {
+		return nil, err
+	}
+	return &trader, nil
+}
+
+func fetchLedger(client *http.Client, address string) (pkg.Ledger, error) {
+	var ledger pkg.Ledger
+	if err := fetchJSON(client, address+"/ledger", &ledger); err != nil {
+		return nil, err
+	}
+	return ledger, ledger.Verify()
+}
+
+func fetchJSON(client *http.Client, url string, value any) error {
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer %s answered with status %d", url, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(value)
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type Server struct {
+	Node        *pkg.Node
+	Transport   *pkg.HTTPTransport
+	Params      pkg.Params
+	VoteTimeout time.Duration
+	Counter     int
+
+	locker      sync.Mutex
+	votes       map[string]chan pkg.Message
+	votesLocker sync.Mutex
+}
+
+func NewServer(trader *pkg.Trader, params pkg.Params, voteTimeout time.Duration) (*Server, error) {
+	if err := trader.SaveTrader(*trader); err != nil {
+		return nil, err
+	}
+	transport := pkg.NewHTTPTransport()
+	server := &Server{
+		Node:        pkg.NewNode(trader, transport),
+		Transport:   transport,
+		Params:      params,
+		VoteTimeout: voteTimeout,
+		votes:       make(map[string]chan pkg.Message),
+	}
+	transport.Register(trader.ID, server.handle)
+	transport.Register(pkg.SystemID, server.handle)
+	return server, nil
+}
+
+func (server *Server) Join(addresses []string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, address := range addresses {
+		for {
+			peer, err := fetchTrader(server.Transport.Client, address)
+			if err == nil {
+				server.Transport.AddPeer(peer.ID, address)
+				server.locker.Lock()
+				err = server.Node.Trader.SaveTrader(*peer)
+				server.locker.Unlock()
+				if err != nil {
+					return err
+				}
+				break
+			} else if time.Now().After(deadline) {
+				return err
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+func (server *Server) RefundExpired(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now().UnixMilli()
+		server.locker.Lock()
+		coins := server.Node.Trader.ExpiredCoins(now)
+		server.locker.Unlock()
+		for _, coin := range coins {
+			log.Printf("Refunding expired coin %s\n", coin.ID)
+			server.broadcast(pkg.Message{Kind: pkg.RefundMessage, Coin: &coin, Time: now})
+		}
+	}
+}
+
+func (server *Server) handle(message pkg.Message) {
+	switch message.Kind {
+	case pkg.VoteMessage:
+		server.votesLocker.Lock()
+		if votes, ok := server.votes[message.Ref]; ok {
+			select {
+			case votes <- message:
+			default:
+			}
+		}
+		server.votesLocker.Unlock()
+	case pkg.ErrorMessage:
+		log.Printf("Error from %s: %s\n", message.From, message.Error)
+	default:
+		server.locker.Lock()
+		defer server.locker.Unlock()
+		if message.Kind == pkg.RefundMessage {
+			message.Time = time.Now().UnixMilli()
+		}
+		known := message.Kind == pkg.FractalMessage && server.Node.Trader.Data.Teams[message.Fractal.ID] != nil
+		server.Node.Handle(message)
+		if message.Kind == pkg.FractalMessage && !known && server.Node.Trader.Data.Teams[message.Fractal.ID] != nil {
+			server.Counter++
+		}
+	}
+}
+
+func (server *Server) members() []string {
+	members := []string{server.Node.Trader.ID}
+	for peerID := range server.Transport.Peers() {
+		members = append(members, peerID)
+	}
+	slices.Sort(members)
+	return members
+}
+
+func (server *Server) broadcast(message pkg.Message) {
+	for _, traderID := range server.members() {
+		message.To = traderID
+		server.send(message)
+	}
+}
+
+func (server *Server) send(message pkg.Message) {
+	message.From = server.Node.Trader.ID
+	if message.Kind.Signed() {
+		server.locker.Lock()
+		err := server.Node.Trader.SignMessage(&message)
+		server.locker.Unlock()
+		if err != nil {
+			log.Printf("Error signing message to %s: %v\n", message.To, err)
+			return
+		}
+	}
+	if err := server.Transport.Send(message); err != nil {
+		log.Printf("Error sending to %s: %v\n", message.To, err)
+	}
+}
+
+func (server *Server) collectVotes(request pkg.Message, team []string) map[string]pkg.Message {
+	votes := make(chan pkg.Message, len(team))
+	server.votesLocker.Lock()
+	server.votes[request.Ref] = votes
+	server.votesLocker.Unlock()
+	defer func() {
+		server.votesLocker.Lock()
+		delete(server.votes, request.Ref)
+		server.votesLocker.Unlock()
+	}()
+
+	request.From = server.Node.Trader.ID
+	for _, traderID := range team {
+		request.To = traderID
+		if err := server.Transport.Send(request); err != nil {
+			log.Printf("Error sending to %s: %v\n", traderID, err)
+		}
+	}
+
+	result := make(map[string]pkg.Message)
+	timeout := time.After(server.VoteTimeout)
+	for len(result) < len(team) {
+		select {
+		case vote := <-votes:
+			result[vote.From] = vote
+		case <-timeout:
+			return result
+		}
+	}
+	return result
+}
+
+func (server *Server) revealVotes(request pkg.Message, team []string) map[string]pkg.Message {
+	commitments := server.collectVotes(request, team)
+	reveal := pkg.Message{Kind: pkg.RevealMessage, Ref: request.Ref + "/reveal", Fractal: request.Fractal, Round: pkg.VerificationRound}
+	if request.Kind == pkg.RoundMessage {
+		reveal.Round = request.Fractal.Round
+	}
+
+	var committed []string
+	server.locker.Lock()
+	for _, traderID := range team {
+		commitment := commitments[traderID].Commitment
+		if commitment == nil || commitment.Voter != traderID {
+			continue
+		} else if err := commitment.Verify(server.publicKey(traderID)); err != nil {
+			log.Printf("Dropping commitment of %s: %v\n", traderID, err)
+			continue
+		}
+		committed = append(committed, traderID)
+	}
+	server.locker.Unlock()
+
+	replies := server.collectVotes(reveal, committed)
+	result := make(map[string]pkg.Message)
+	for _, traderID := range committed {
+		commitment := commitments[traderID].Commitment
+		reply, ok := replies[traderID]
+		if ok && commitment.Opens(reply.Votes, reply.Nonce) == nil {
+			result[traderID] = reply
+			continue
+		}
+		log.Printf("Trader %s did not reveal its votes on %s\n", traderID, request.Fractal.ID)
+	}
+	return result
+}
+
+func (server *Server) castVotes(request pkg.Message, team []string) []pkg.Vote {
+	var replies map[string]pkg.Message
+	if server.Params.CommitReveal {
+		replies = server.revealVotes(request, team)
+	} else {
+		replies = server.collectVotes(request, team)
+	}
+	server.locker.Lock()
+	var votes []pkg.Vote
+	for _, traderID := range team {
+		reply, ok := replies[traderID]
+		if !ok {
+			continue
+		} else if err := pkg.VerifyVotes(reply.Votes, server.publicKey); err != nil {
+			log.Printf("Dropping votes of %s: %v\n", traderID, err)
+			continue
+		}
+		for _, vote := range reply.Votes {
+			if vote.Voter == traderID {
+				votes = append(votes, vote)
+			}
+		}
+	}
+	server.locker.Unlock()
+
+	equivocators := make(map[string]bool)
+	for _, equivocation := range pkg.FindEquivocations(votes) {
+		log.Printf("Trader %s equivocated on %s\n", equivocation.First.Voter, equivocation.First.Subject())
+		equivocators[equivocation.First.Voter] = true
+		server.broadcast(pkg.Message{Kind: pkg.SlashMessage, Fault: &pkg.Fault{Type: pkg.Equivocating, Offender: equivocation.First.Voter, FractalID: equivocation.First.FractalID, Equivocation: &equivocation}})
+	}
+	return slices.DeleteFunc(votes, func(vote pkg.Vote) bool {
+		return equivocators[vote.Voter]
+	})
+}
+
+func (server *Server) publicKey(traderID string) *tools.PublicKey {
+	if trader, ok := server.Node.Trader.Data.Traders[traderID]; ok {
+		return trader.PublicKey
+	}
+	return nil
+}
+
+func (server *Server) certify(votes []pkg.Vote, team []string, weights []float64, fractal *pkg.FractalRing, ringID string, round int) pkg.Certificate {
+	var matching []pkg.Vote
+	for _, vote := range votes {
+		if vote.FractalID == fractal.ID && vote.RingID == ringID && vote.Round == round {
+			matching = append(matching, vote)
+		}
+	}
+	certificate := pkg.NewCertificate(fractal.ID, ringID, round, team, matching, server.Params.Quorum, weights)
+
+	decision := pkg.Message{Kind: pkg.ReputationMessage, Certificate: &certificate}
+	if round == pkg.VerificationRound {
+		decision.Fractal = clone(fractal)
+	}
+	if server.Params.BanMinority {
+		ban := decision
+		ban.Kind = pkg.BanMessage
+		for _, traderID := range certificate.Minority() {
+			ban.To = traderID
+			server.send(ban)
+		}
+	}
+	server.broadcast(decision)
+	return certificate
+}
+
+func (server *Server) Propose() (*pkg.FractalRing, error) {
+	server.locker.Lock()
+	fractal := server.Node.Trader.CheckForRings(server.Counter)
+	var weights []float64
+	if fractal != nil {
+		weights = pkg.VoteWeights(fractal.VerificationTeam, server.Node.Trader.VoteWeigher())
+	}
+	server.locker.Unlock()
+	if fractal == nil {
+		return nil, nil
+	}
+
+	votes := server.castVotes(pkg.Message{Kind: pkg.VerifyMessage, Ref: fractal.ID, Fractal: fractal}, fractal.VerificationTeam)
+	certificate := server.certify(votes, fractal.VerificationTeam, weights, fractal, "", pkg.VerificationRound)
+	if !certificate.Accepted {
+		server.locker.Lock()
+		server.Node.Trader.RemoveFractalRing(fractal.ID)
+		server.locker.Unlock()
+		return fractal, errors.New("fractal ring verification failed")
+	}
+
+	fractal.Certificates = append(fractal.Certificates, certificate)
+	server.broadcast(pkg.Message{Kind: pkg.FractalMessage, Fractal: fractal})
+	if server.Params.RunFractals {
+		go server.runRounds(clone(fractal))
+	}
+	return fractal, nil
+}
+
+func (server *Server) runRounds(fractal *pkg.FractalRing) {
+	certificates := make(map[string]pkg.Certificate)
+	for round := 0; round < server.Params.RoundsCount; round++ {
+		time.Sleep(time.Duration(server.Params.RoundLength) * time.Millisecond)
+		fractal.Round = round
+		ref := fmt.Sprintf("%s/%d", fractal.ID, round)
+		votes := server.castVotes(pkg.Message{Kind: pkg.RoundMessage, Ref: ref, Fractal: clone(fractal)}, fractal.VerificationTeam)
+		for index, ring := range fractal.CooperationRings {
+			if ring.Rounds != -1 {
+				continue
+			}
+
+			certificate := server.certify(votes, fractal.VerificationTeam, fractal.Weights(), fractal, ring.ID, round)
+			if certificate.Accepted {
+				certificates[ring.ID] = certificate
+				continue
+			}
+			ring.Rounds = round
+			fractal.CooperationRings[index] = ring
+			server.payout(ring, float64(round)/float64(server.Params.RoundsCount), certificate)
+		}
+	}
+
+	for index, ring := range fractal.CooperationRings {
+		if ring.Rounds == -1 {
+			ring.Rounds = server.Params.RoundsCount
+			fractal.CooperationRings[index] = ring
+			server.payout(ring, 1, certificates[ring.ID])
+		}
+	}
+}
+
+func (server *Server) payout(ring pkg.CooperationTable, share float64, certificate pkg.Certificate) {
+	server.locker.Lock()
+	coins := server.Node.Trader.Data.Coins
+	money := coins[ring.CoinIDs[0]].Amount * share
+	paid := ring.Rounds >= server.Params.RoundsCount
+	payments := make([]pkg.Payment, 0, len(ring.CoinIDs))
+	for _, coinID := range ring.CoinIDs {
+		coin := coins[coinID]
+		payment := pkg.Payment{Owner: coin.Owner, Coin: coinID, Amount: money * coin.Amount / ring.Weight}
+		if paid {
+			payment.Prize = server.Params.FractalPrize
+		}
+		payments = append(payments, payment)
+	}
+	server.locker.Unlock()
+
+	server.broadcast(pkg.Message{Kind: pkg.PayoutMessage, Ring: &ring, Payments: payments, OK: paid, Certificate: &certificate})
+}
+
+func clone(fractal *pkg.FractalRing) *pkg.FractalRing {
+	copied := *fractal
+	copied.CooperationRings = slices.Clone(fractal.CooperationRings)
+	return &copied
+}
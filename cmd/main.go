@@ -3,94 +3,287 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 	"time"
 
 	"github.com/Arka-Lab/LoR/internal"
 	"github.com/Arka-Lab/LoR/pkg"
 )
 
-func ParseFlags() (int, time.Duration, int, int, int, string, string) {
+type Flags struct {
+	NumTypes     int
+	MaxTicks     int64
+	MaxCoins     int
+	NumTraders   int
+	NumRandoms   int
+	NumBads      int
+	NumColluders int
+	NumCopycats  int
+	Seed         uint64
+	Params       pkg.Params
+	Overrides    func(base pkg.Params) (pkg.Params, error)
+	SaveTo       string
+	LoadFrom     string
+	Resume       string
+	Format       string
+	Transport    string
+	Network      *internal.NetworkFaults
+	Sybil        internal.SybilAttack
+	Churn        internal.Churn
+	Checkpoint   internal.Checkpoint
+}
+
+func ParseFlags() Flags {
 	typesPtr := flag.Int("type", 3, "number of coin types")
-	runTimePtr := flag.Int("time", 60, "run time in seconds")
+	runTimePtr := flag.Int("time", 60, "virtual run time in seconds")
+	ticksPtr := flag.Int64("ticks", 0, "virtual run time in ticks (overrides -time)")
+	coinsPtr := flag.Int("coins", 0, "maximum number of coins to create (0 for no limit)")
 	tradersPtr := flag.Int("trader", 100, "number of traders")
 	randomsPtr := flag.Int("random", 0, "number of random traders")
 	badsPtr := flag.Int("bad", 0, "number of bad traders")
-	alphaPtr := flag.Float64("alpha", pkg.BadBehavior, "bad behavior percentage")
+	colludersPtr := flag.Int("collude", 0, "number of colluding traders")
+	copycatsPtr := flag.Int("copycat", 0, "number of traders that copy the votes cast before theirs")
+	seedPtr := flag.Uint64("seed", 0, "random seed (0 for a random seed)")
+	sybilsPtr := flag.Int("sybil", 0, "number of sybil traders minted by the attacker during the run")
+	sybilWavesPtr := flag.Int("sybil-waves", 1, "number of waves the sybil traders join in")
+	sybilStartPtr := flag.Int64("sybil-start", 0, "virtual tick of the first sybil wave")
+	sybilIntervalPtr := flag.Int64("sybil-interval", 0, "virtual ticks between sybil waves (0 for 10 rounds)")
+	sybilAccountPtr := flag.Float64("sybil-account", 10, "account of each sybil trader")
+	churnIntervalPtr := flag.Int64("churn-interval", 0, "virtual ticks between churn events (0 for 10 rounds)")
+	churnJoinPtr := flag.Int("churn-join", 0, "number of traders joining at each churn event")
+	churnLeavePtr := flag.Int("churn-leave", 0, "number of traders leaving at each churn event")
 	saveTohPtr := flag.String("save-to", "system.json", "file path to save system")
 	loadFromhPtr := flag.String("load-from", "", "file path to load system")
+	resumePtr := flag.String("resume", "", "file path of a snapshot to continue running (-time/-ticks are added to the tick it stopped at)")
+	checkpointDirPtr := flag.String("checkpoint-dir", "", "directory of periodic checkpoints (restarts from the latest one if present)")
+	checkpointIntervalPtr := flag.Int64("checkpoint-interval", 0, "virtual ticks between checkpoints (0 to disable)")
+	checkpointCoinsPtr := flag.Int("checkpoint-coins", 0, "coins created between checkpoints (0 to disable)")
+	checkpointFractalsPtr := flag.Int("checkpoint-fractals", 0, "fractal rings proposed between checkpoints (0 to disable)")
+	checkpointKeepPtr := flag.Int("checkpoint-keep", 3, "number of checkpoints to keep (0 to keep all)")
+	networkPtr := flag.String("network", "", "JSON file of network faults to inject (latency, loss, partitions, ...)")
+	transportPtr := flag.String("transport", "local", "message transport between traders (local or channel)")
+	formatPtr := flag.String("format", "text", "analysis output format (text, json or csv)")
+	parseParams := internal.BindParams(flag.CommandLine, true)
 	flag.Parse()
 
+	params, err := parseParams(pkg.DefaultParams())
+	if err != nil {
+		log.Fatalf("Invalid protocol parameters: %v\n", err)
+	}
+	if *sybilIntervalPtr == 0 {
+		*sybilIntervalPtr = 10 * params.RoundLength
+	}
+	if *churnIntervalPtr == 0 {
+		*churnIntervalPtr = 10 * params.RoundLength
+	}
+
 	if *typesPtr < 1 {
 		log.Fatalf("Number of types must be positive\n")
 	} else if *tradersPtr < 1 {
 		log.Fatalf("Number of traders must be positive\n")
 	}
-	numTypes, numTraders := *typesPtr, *tradersPtr
 
-	if *runTimePtr < 0 {
+	if *runTimePtr < 0 || *ticksPtr < 0 {
 		log.Fatalf("Run time must be non-negative\n")
+	} else if *coinsPtr < 0 {
+		log.Fatalf("Number of coins must be non-negative\n")
+	}
+	maxTicks := *ticksPtr
+	if maxTicks == 0 {
+		maxTicks = int64(time.Duration(*runTimePtr) * time.Second / time.Millisecond)
+	}
+
+	if *randomsPtr < 0 || *badsPtr < 0 || *colludersPtr < 0 || *copycatsPtr < 0 {
+		log.Fatalf("Number of random, bad, colluding and copycat traders must be non-negative\n")
+	} else if *randomsPtr+*badsPtr+*colludersPtr+*copycatsPtr > *tradersPtr {
+		log.Fatalf("Number of random, bad, colluding and copycat traders must be less than the total number of traders\n")
+	}
+
+	if *sybilsPtr < 0 || *sybilWavesPtr < 1 {
+		log.Fatalf("Number of sybil traders must be non-negative and waves must be positive\n")
+	} else if *sybilStartPtr < 0 || *sybilIntervalPtr < 0 || *sybilAccountPtr < 0 {
+		log.Fatalf("Sybil start, interval and account must be non-negative\n")
+	}
+
+	if *churnIntervalPtr < 1 || *churnJoinPtr < 0 || *churnLeavePtr < 0 {
+		log.Fatalf("Churn interval must be positive and churn counts must be non-negative\n")
 	}
-	runTime := time.Duration(*runTimePtr) * time.Second
 
-	if *randomsPtr < 0 || *badsPtr < 0 {
-		log.Fatalf("Number of random and bad traders must be non-negative\n")
-	} else if *randomsPtr+*badsPtr > numTraders {
-		log.Fatalf("Number of random and bad traders must be less than the total number of traders\n")
+	if *checkpointIntervalPtr < 0 || *checkpointCoinsPtr < 0 || *checkpointFractalsPtr < 0 || *checkpointKeepPtr < 0 {
+		log.Fatalf("Checkpoint interval, coins, fractals and keep must be non-negative\n")
+	} else if *checkpointDirPtr != "" && *checkpointIntervalPtr == 0 && *checkpointCoinsPtr == 0 && *checkpointFractalsPtr == 0 {
+		log.Fatalf("Checkpoint directory needs a checkpoint interval, coins or fractals\n")
 	}
-	numRandoms, numBads := *randomsPtr, *badsPtr
 
-	saveTo, loadFrom := *saveTohPtr, *loadFromhPtr
+	if *loadFromhPtr != "" && *resumePtr != "" {
+		log.Fatalf("Only one of load-from and resume can be used\n")
+	}
 
-	if *alphaPtr < 0 || *alphaPtr > 1 {
-		log.Fatalf("Bad behavior percentage must be between 0 and 1\n")
+	if *transportPtr != "local" && *transportPtr != "channel" {
+		log.Fatalf("Transport must be local or channel\n")
+	}
+	var network *internal.NetworkFaults
+	if *networkPtr != "" {
+		faults, err := internal.LoadNetworkFaults(*networkPtr)
+		if err != nil {
+			log.Fatalf("Invalid network faults: %v\n", err)
+		} else if *transportPtr != "local" {
+			log.Fatalf("Network faults need the local transport\n")
+		}
+		network = &faults
 	}
-	pkg.BadBehavior = *alphaPtr
 
-	return numTypes, runTime, numTraders, numRandoms, numBads, saveTo, loadFrom
+	if *formatPtr != "text" && *formatPtr != "json" && *formatPtr != "csv" {
+		log.Fatalf("Output format must be text, json or csv\n")
+	}
+
+	return Flags{
+		NumTypes:     *typesPtr,
+		MaxTicks:     maxTicks,
+		MaxCoins:     *coinsPtr,
+		NumTraders:   *tradersPtr,
+		NumRandoms:   *randomsPtr,
+		NumBads:      *badsPtr,
+		NumColluders: *colludersPtr,
+		NumCopycats:  *copycatsPtr,
+		Seed:         *seedPtr,
+		Params:       params,
+		Overrides:    parseParams,
+		SaveTo:       *saveTohPtr,
+		LoadFrom:     *loadFromhPtr,
+		Resume:       *resumePtr,
+		Format:       *formatPtr,
+		Transport:    *transportPtr,
+		Network:      network,
+		Sybil: internal.SybilAttack{
+			Count:    *sybilsPtr,
+			Waves:    *sybilWavesPtr,
+			Start:    *sybilStartPtr,
+			Interval: *sybilIntervalPtr,
+			Account:  *sybilAccountPtr,
+		},
+		Churn: internal.Churn{
+			Interval: *churnIntervalPtr,
+			Joins:    *churnJoinPtr,
+			Leaves:   *churnLeavePtr,
+		},
+		Checkpoint: internal.Checkpoint{
+			Dir:      *checkpointDirPtr,
+			Interval: *checkpointIntervalPtr,
+			Coins:    *checkpointCoinsPtr,
+			Fractals: *checkpointFractalsPtr,
+			Keep:     *checkpointKeepPtr,
+		},
+	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "sweep" {
+		RunSweep(os.Args[2:])
+		return
+	}
+
 	logger := log.Default()
 	var system *internal.System
-	numTypes, runTime, numTraders, numRandoms, numBads, saveTo, loadFrom := ParseFlags()
+	flags := ParseFlags()
 
-	if loadFrom == "" {
-		finish := make(chan bool, 1)
-		system = internal.NewSystem()
+	if flags.LoadFrom != "" {
+		s, err := internal.Load(flags.LoadFrom)
+		if err != nil {
+			logger.Fatalf("Error loading system: %v\n", err)
+		}
 
-		logger.Printf("Starting simulation with %d types (alpha = %.2f%%)...\n", numTypes, pkg.BadBehavior*100)
-		if err := system.Init(numTraders, numRandoms, numBads, uint(numTypes)); err != nil {
-			logger.Fatalf("Error initializing system: %v\n", err)
+		system = s
+		logger.Printf("Simulation loaded from %s\n", flags.LoadFrom)
+	} else {
+		if flags.Checkpoint.Dir != "" {
+			if s, path, err := internal.LoadLatestCheckpoint(flags.Checkpoint.Dir); err == nil {
+				system = s
+				system.Checkpoint.Dir = flags.Checkpoint.Dir
+				UseTransport(system, flags.Transport)
+				logger.Printf("Restarting simulation from checkpoint %s at tick %d...\n", path, system.Scheduler.Clock)
+				system.Continue()
+				logger.Println("Simulation stopped!")
+			}
 		}
-		logger.Println("Simulation initialized!")
-
-		logger.Println("Starting simulation...")
-		done := make(chan bool, 1)
-		go func() {
-			system.Start(finish)
-			done <- true
-		}()
-		logger.Println("Simulation started!")
-
-		logger.Printf("Waiting for %s...\n", runTime)
-		time.Sleep(runTime)
-		finish <- true
-		<-done
-		logger.Println("Simulation stopped!")
-
-		if err := system.Save(saveTo); err != nil {
+		if system == nil {
+			system = StartSystem(flags, logger)
+		}
+
+		if err := system.Save(flags.SaveTo); err != nil {
 			logger.Fatalf("Error saving system: %v\n", err)
 		}
-		logger.Printf("System saved to %s\n", saveTo)
+		logger.Printf("System saved to %s\n", flags.SaveTo)
+	}
+
+	report := internal.AnalyzeSystem(system)
+	if err := report.Write(os.Stdout, flags.Format); err != nil {
+		logger.Fatalf("Error writing report: %v\n", err)
+	}
+}
+
+func StartSystem(flags Flags, logger *log.Logger) *internal.System {
+	var system *internal.System
+	maxTicks := flags.MaxTicks
+	if flags.Resume == "" {
+		system = internal.NewSystem(flags.Seed, flags.Params)
+
+		logger.Printf("Starting simulation with %d types (alpha = %.2f%%, seed %d)...\n", flags.NumTypes, system.Params.BadBehavior*100, system.Seed)
+		if flags.Network != nil {
+			system.EnableNetworkFaults(*flags.Network)
+		}
+		UseTransport(system, flags.Transport)
+		if err := system.Init(flags.NumTraders, flags.NumRandoms, flags.NumBads, flags.NumColluders, flags.NumCopycats, uint(flags.NumTypes)); err != nil {
+			logger.Fatalf("Error initializing system: %v\n", err)
+		}
 	} else {
-		s, err := internal.Load(loadFrom)
+		s, err := internal.Load(flags.Resume)
 		if err != nil {
 			logger.Fatalf("Error loading system: %v\n", err)
+		} else if !s.Resumable() {
+			logger.Fatalf("Snapshot %s does not contain trader data and cannot be resumed\n", flags.Resume)
+		}
+
+		params, err := flags.Overrides(s.Params)
+		if err != nil {
+			logger.Fatalf("Invalid protocol parameters: %v\n", err)
+		} else if err := s.SetParams(params); err != nil {
+			logger.Fatalf("Error applying protocol parameters: %v\n", err)
 		}
 
 		system = s
-		logger.Printf("Simulation loaded from %s\n", loadFrom)
+		if flags.Network != nil {
+			system.EnableNetworkFaults(*flags.Network)
+		}
+		UseTransport(system, flags.Transport)
+		maxTicks += max(system.Horizon, system.Scheduler.Clock)
+		logger.Printf("Resuming simulation from %s at tick %d (alpha = %.2f%%)...\n", flags.Resume, system.Scheduler.Clock, system.Params.BadBehavior*100)
+	}
+	if flags.Sybil.Count > 0 {
+		system.ScheduleSybilAttack(flags.Sybil)
+	}
+	if flags.Churn.Joins > 0 || flags.Churn.Leaves > 0 {
+		system.ScheduleChurn(flags.Churn)
 	}
+	if flags.Checkpoint.Dir != "" {
+		if err := system.EnableCheckpoints(flags.Checkpoint); err != nil {
+			logger.Fatalf("Error enabling checkpoints: %v\n", err)
+		}
+	} else {
+		system.Checkpoint = nil
+	}
+	logger.Println("Simulation initialized!")
 
-	internal.AnalyzeSystem(system)
+	logger.Printf("Running simulation until tick %d (coin limit %d)...\n", maxTicks, flags.MaxCoins)
+	system.Start(maxTicks, flags.MaxCoins)
+	logger.Println("Simulation stopped!")
+	return system
+}
+
+func UseTransport(system *internal.System, transport string) {
+	if transport == "channel" && system.Network != nil {
+		log.Fatalf("Network faults need the local transport\n")
+	} else if transport == "channel" {
+		system.UseTransport(pkg.NewChannelTransport())
+	}
 }
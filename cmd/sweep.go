@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"runtime"
+	"time"
+
+	"github.com/Arka-Lab/LoR/internal"
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func RunSweep(args []string) {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	randomPtr := flags.String("random", "0", "random trader percentages (start:end:step or comma separated)")
+	badPtr := flags.String("bad", "0", "bad trader percentages (start:end:step or comma separated)")
+	alphaPtr := flags.String("alpha", "10", "bad behavior percentages (start:end:step or comma separated)")
+	tradersPtr := flags.Int("trader", 500, "number of traders")
+	typesPtr := flags.Int("type", 3, "number of coin types")
+	repeatsPtr := flags.Int("repeats", 1, "number of repeats per point")
+	workersPtr := flags.Int("workers", runtime.NumCPU(), "number of points to run in parallel")
+	runTimePtr := flags.Int("time", 600, "virtual run time in seconds")
+	coinsPtr := flags.Int("coins", 0, "maximum number of coins to create (0 for no limit)")
+	seedPtr := flags.Uint64("seed", 0, "base random seed (0 for random seeds)")
+	dirPtr := flags.String("dir", "result", "directory of per-point snapshots and results")
+	outPtr := flags.String("out", "results.csv", "file path of the combined results")
+	parseParams := internal.BindParams(flags, false)
+	flags.Parse(args)
+
+	params, err := parseParams(pkg.DefaultParams())
+	if err != nil {
+		log.Fatalf("Invalid protocol parameters: %v\n", err)
+	}
+
+	randoms, err := internal.ParseRange(*randomPtr)
+	if err != nil {
+		log.Fatalf("Invalid random range: %v\n", err)
+	}
+	bads, err := internal.ParseRange(*badPtr)
+	if err != nil {
+		log.Fatalf("Invalid bad range: %v\n", err)
+	}
+	alphas, err := internal.ParseRange(*alphaPtr)
+	if err != nil {
+		log.Fatalf("Invalid alpha range: %v\n", err)
+	}
+
+	if *tradersPtr < 1 || *typesPtr < 1 || *repeatsPtr < 1 || *workersPtr < 1 {
+		log.Fatalf("Number of traders, types, repeats and workers must be positive\n")
+	} else if *runTimePtr < 0 || *coinsPtr < 0 {
+		log.Fatalf("Run time and number of coins must be non-negative\n")
+	}
+
+	sweep := internal.Sweep{
+		Params:   params,
+		Randoms:  randoms,
+		Bads:     bads,
+		Alphas:   alphas,
+		Traders:  *tradersPtr,
+		Types:    uint(*typesPtr),
+		Repeats:  *repeatsPtr,
+		Workers:  *workersPtr,
+		MaxTicks: int64(time.Duration(*runTimePtr) * time.Second / time.Millisecond),
+		MaxCoins: *coinsPtr,
+		Seed:     *seedPtr,
+		Dir:      *dirPtr,
+	}
+
+	log.Printf("Running %d sweep points with %d workers...\n", len(sweep.Points()), sweep.Workers)
+	if err := internal.WriteFile(*outPtr, sweep.Run); err != nil {
+		log.Fatalf("Error running sweep: %v\n", err)
+	}
+	log.Printf("Results saved to %s\n", *outPtr)
+}
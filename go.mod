@@ -3,6 +3,7 @@ module github.com/Arka-Lab/LoR
 go 1.23.2
 
 require (
+	filippo.io/edwards25519 v1.1.1
 	github.com/google/uuid v1.6.0
 	golang.org/x/crypto v0.27.0
 	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0
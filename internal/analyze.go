@@ -1,44 +1,46 @@
 package internal
 
 import (
-	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/Arka-Lab/LoR/pkg"
 )
 
-func AnalyzeSystem(system *System) {
-	fmt.Println("Number of coins:", len(system.Coins))
-	fmt.Println("Number of fractal rings:", len(system.Fractals))
+func AnalyzeSystem(system *System) Report {
+	report := Report{
+		Coins:                len(system.Coins),
+		Fractals:             len(system.Fractals),
+		InvalidAcceptFractal: system.BadAcceptCount,
+		ValidRejectFractal:   system.BadRejectCount,
+		RunFractals:          system.Params.RunFractals,
+	}
 
-	runCoins := 0
 	for _, coin := range system.Coins {
 		if coin.Status == pkg.Run {
-			runCoins++
+			report.RunCoins++
 		}
 	}
-	fmt.Println("Number of run coins:", runCoins)
 
 	numSubmitted, totalSubmitted, acceptRate := 0, 0, 0.0
-	for traderID := range system.Traders {
+	for _, traderID := range slices.Sorted(maps.Keys(system.Traders)) {
 		if system.SubmitCount[traderID] > 0 {
 			numSubmitted++
 			totalSubmitted += system.SubmitCount[traderID]
 			acceptRate += float64(system.AcceptedCount[traderID]) / float64(system.SubmitCount[traderID])
 		}
 	}
-	fmt.Printf("Average number of submitted fractal rings per trader: %.2f\n", float64(totalSubmitted)/float64(numSubmitted))
-	fmt.Printf("Average fractal ring acceptance rate per trader: %.2f%%\n", acceptRate/float64(numSubmitted)*100)
-
-	fmt.Println("Number of invalid accepted fractal rings:", system.BadAcceptCount)
-	fmt.Println("Number of valid rejected fractal rings:", system.BadRejectCount)
+	report.SubmitFractal = ratio(float64(totalSubmitted), float64(numSubmitted))
+	report.AcceptFractal = ratio(acceptRate, float64(numSubmitted)) * 100
 
-	if RunFractals {
+	if system.Params.RunFractals {
 		coinsCount, coinsTotal := 0, 0.
 		coinsSatisfaction := make(map[string]float64)
-		for _, fractal := range system.Fractals {
+		for _, fractalID := range slices.Sorted(maps.Keys(system.Fractals)) {
+			fractal := system.Fractals[fractalID]
 			for _, ring := range fractal.CooperationRings {
 				if ring.Rounds != -1 {
-					satisfaction := float64(ring.Rounds) / float64(pkg.RoundsCount)
+					satisfaction := float64(ring.Rounds) / float64(system.Params.RoundsCount)
 					if !ring.IsValid {
 						satisfaction *= -1
 					}
@@ -51,23 +53,25 @@ func AnalyzeSystem(system *System) {
 				}
 			}
 		}
-		fmt.Printf("Average satisfaction per coin: %.2f%%\n", float64(coinsTotal)/float64(coinsCount)*100)
+		report.CoinSatisfaction = ratio(coinsTotal, float64(coinsCount)) * 100
 
 		traderSatisfaction := make(map[string][]float64)
-		for coinID, satisfaction := range coinsSatisfaction {
+		for _, coinID := range slices.Sorted(maps.Keys(coinsSatisfaction)) {
+			satisfaction := coinsSatisfaction[coinID]
 			owner := system.Coins[coinID].Owner
 			traderSatisfaction[owner] = append(traderSatisfaction[owner], satisfaction)
 		}
 
 		tradersTotal := 0.
-		for _, satisfactions := range traderSatisfaction {
+		for _, traderID := range slices.Sorted(maps.Keys(traderSatisfaction)) {
+			satisfactions := traderSatisfaction[traderID]
 			total := 0.
 			for _, satisfaction := range satisfactions {
 				total += satisfaction
 			}
 			tradersTotal += total / float64(len(satisfactions))
 		}
-		fmt.Printf("Average satisfaction per trader: %.2f%%\n", float64(tradersTotal)/float64(len(traderSatisfaction))*100)
+		report.TraderSatisfaction = ratio(tradersTotal, float64(len(traderSatisfaction))) * 100
 
 		hasFractal := make(map[string]map[string]bool)
 		communicationCount := make(map[string]int)
@@ -90,19 +94,17 @@ func AnalyzeSystem(system *System) {
 			}
 		}
 
-		tradersCount := 0
-		totalAdjacency, maximumAdjacency := 0, 0
+		tradersCount, totalAdjacency := 0, 0
 		for traderID := range system.Traders {
 			if communicationCount[traderID] > 0 {
 				tradersCount++
 				totalAdjacency += communicationCount[traderID]
-				if communicationCount[traderID] > maximumAdjacency {
-					maximumAdjacency = communicationCount[traderID]
+				if communicationCount[traderID] > report.MaxAdjacency {
+					report.MaxAdjacency = communicationCount[traderID]
 				}
 			}
 		}
-		fmt.Printf("Average adjacency per trader: %.2f\n", float64(totalAdjacency)/float64(tradersCount))
-		fmt.Println("Maximum adjacency per trader:", maximumAdjacency)
+		report.AverageAdjacency = ratio(float64(totalAdjacency), float64(tradersCount))
 
 		ringCount := make(map[string]int)
 		for traderID := range system.Traders {
@@ -116,12 +118,403 @@ func AnalyzeSystem(system *System) {
 				}
 			}
 		}
-		maxRings := 0
 		for _, count := range ringCount {
-			if count > maxRings {
-				maxRings = count
+			if count > report.MaxCooperation {
+				report.MaxCooperation = count
+			}
+		}
+	}
+
+	if len(system.Coalition.Members) > 0 {
+		report.Coalition = analyzeCoalition(system)
+	}
+	if system.SybilAttack != nil {
+		report.SybilPhases = analyzeSybil(system)
+	}
+	if len(system.Joined) > 0 || len(system.Retired) > 0 {
+		report.Churn = analyzeChurn(system)
+	}
+	if system.Network != nil {
+		report.Network = analyzeNetwork(system)
+	}
+	report.Votes = analyzeVotes(system)
+	report.Slashing = analyzeSlashing(system)
+	report.Reputation = analyzeReputation(system)
+	report.Voting = analyzeVoting(system)
+	report.Copycat = analyzeCopycat(system)
+	if system.Params.Ledger {
+		report.Ledger = analyzeLedger(system)
+	}
+	if system.Supply != nil {
+		supply := *system.Supply
+		report.Supply = &supply
+	}
+	report.Matching = analyzeMatching(system)
+	return report
+}
+
+func analyzeMatching(system *System) *MatchingReport {
+	matched := make(map[string]int64)
+	for _, proposal := range system.Proposals {
+		fractal, ok := system.Fractals[proposal.FractalID]
+		if !ok || !proposal.Accepted {
+			continue
+		}
+		for _, ring := range fractal.CooperationRings {
+			for _, coinID := range ring.CoinIDs {
+				if _, ok := matched[coinID]; !ok {
+					matched[coinID] = proposal.Time - system.Coins[coinID].Created
+				}
+			}
+		}
+	}
+
+	report := &MatchingReport{Matched: len(matched)}
+	for _, coin := range system.Coins {
+		switch coin.Status {
+		case pkg.Refunded:
+			report.Refunded++
+			report.RefundedAmount += coin.Amount
+		case pkg.Run:
+			report.Running++
+		}
+	}
+	if report.Matched == 0 && report.Refunded == 0 {
+		return nil
+	}
+
+	var latencies []int64
+	types := make(map[uint][]int64)
+	for _, coinID := range slices.Sorted(maps.Keys(matched)) {
+		coinType := system.Coins[coinID].Type
+		latencies = append(latencies, matched[coinID])
+		types[coinType] = append(types[coinType], matched[coinID])
+	}
+	report.Latency = latencyOf(latencies)
+	for _, coinType := range slices.Sorted(maps.Keys(types)) {
+		report.CoinTypes = append(report.CoinTypes, CoinLatencyReport{Type: coinType, LatencyReport: latencyOf(types[coinType])})
+	}
+	return report
+}
+
+func latencyOf(latencies []int64) LatencyReport {
+	if len(latencies) == 0 {
+		return LatencyReport{}
+	}
+
+	slices.Sort(latencies)
+	total := int64(0)
+	for _, latency := range latencies {
+		total += latency
+	}
+	rank := func(percentile int) int64 {
+		return latencies[(percentile*len(latencies)+99)/100-1]
+	}
+	return LatencyReport{
+		Count: len(latencies),
+		Mean:  float64(total) / float64(len(latencies)),
+		P50:   rank(50),
+		P90:   rank(90),
+		P99:   rank(99),
+		Max:   latencies[len(latencies)-1],
+	}
+}
+
+func analyzeVoting(system *System) *VotingReport {
+	if system.Params.VotingRule == pkg.CountVotes && system.Params.Quorum == pkg.MajorityQuorum {
+		return nil
+	}
+
+	report := &VotingReport{
+		Rule:       string(system.Params.VotingRule),
+		Quorum:     system.Params.Quorum,
+		Rounds:     system.RoundDecisions,
+		RoundFlips: system.RoundFlips,
+	}
+	for _, proposal := range system.Proposals {
+		report.Verifications++
+		if proposal.Verified != proposal.CountVerified {
+			report.VerificationFlips++
+		}
+		if proposal.IsValid && !proposal.Verified {
+			report.ValidRejected++
+		} else if !proposal.IsValid && proposal.Verified {
+			report.InvalidAccepted++
+		}
+		if proposal.IsValid && !proposal.CountVerified {
+			report.CountValidRejected++
+		} else if !proposal.IsValid && proposal.CountVerified {
+			report.CountInvalidAccepted++
+		}
+	}
+	return report
+}
+
+const reputationPhases = 4
+
+func analyzeReputation(system *System) *ReputationReport {
+	if len(system.Proposals) == 0 {
+		return nil
+	}
+
+	report := &ReputationReport{}
+	normalCount, badCount, normalTotal, badTotal := 0, 0, 0., 0.
+	for _, traderID := range system.traderIDs {
+		trader := system.Traders[traderID]
+		if trader.Data == nil {
+			continue
+		}
+		score := system.Reputations[traderID].Score()
+		if trader.Data.TraderType == pkg.Normal {
+			normalCount++
+			normalTotal += score
+		} else {
+			badCount++
+			badTotal += score
+		}
+	}
+	report.BadTraders = ratio(float64(badCount), float64(normalCount+badCount)) * 100
+	report.NormalScore = ratio(normalTotal, float64(normalCount))
+	report.BadScore = ratio(badTotal, float64(badCount))
+
+	for phase := range reputationPhases {
+		proposals := system.Proposals[phase*len(system.Proposals)/reputationPhases : (phase+1)*len(system.Proposals)/reputationPhases]
+		seats, badSeats := 0, 0
+		for _, proposal := range proposals {
+			seats += proposal.TeamSize
+			badSeats += proposal.BadSeats
+		}
+
+		phaseReport := ReputationPhaseReport{Fractals: len(proposals), BadSeats: ratio(float64(badSeats), float64(seats)) * 100}
+		if len(proposals) > 0 {
+			phaseReport.Time = proposals[0].Time
+		}
+		report.Phases = append(report.Phases, phaseReport)
+	}
+	return report
+}
+
+func analyzeSlashing(system *System) *SlashReport {
+	if len(system.Slashes) == 0 && system.MinorityVotes == 0 {
+		return nil
+	}
+
+	behaviors := make(map[pkg.BehaviorType]*BehaviorSlashReport)
+	report := &SlashReport{MinorityVotes: system.MinorityVotes}
+	for behavior := pkg.Normal; behavior <= pkg.Copycat; behavior++ {
+		report.Behaviors = append(report.Behaviors, BehaviorSlashReport{Behavior: behavior.String()})
+	}
+	for index := range report.Behaviors {
+		behaviors[pkg.BehaviorType(index)] = &report.Behaviors[index]
+	}
+
+	for _, slash := range system.Slashes {
+		behavior, ok := behaviors[slash.Behavior]
+		if !ok {
+			continue
+		}
+		switch slash.Type {
+		case pkg.Equivocating:
+			behavior.Equivocations++
+		case pkg.InvalidProposal:
+			behavior.InvalidProposals++
+		case pkg.InvalidApproval:
+			behavior.InvalidApprovals++
+		case pkg.Unrevealed:
+			behavior.Unrevealed++
+		}
+		behavior.Slashed += slash.Amount
+	}
+	return report
+}
+
+func analyzeCopycat(system *System) *CopycatReport {
+	var copycat, normal pkg.Reputation
+	report := &CopycatReport{CommitReveal: system.Params.CommitReveal}
+	normalCount := 0
+	for _, traderID := range system.traderIDs {
+		trader := system.Traders[traderID]
+		if trader.Data == nil {
+			continue
+		}
+		switch trader.Data.TraderType {
+		case pkg.Copycat:
+			report.Members++
+			copycat = copycat.Add(system.Reputations[traderID])
+		case pkg.Normal:
+			normalCount++
+			normal = normal.Add(system.Reputations[traderID])
+		}
+	}
+	if report.Members == 0 {
+		return nil
+	}
+
+	for _, slash := range system.Slashes {
+		if slash.Behavior == pkg.Copycat && slash.Type == pkg.Unrevealed {
+			report.Unrevealed++
+			report.Slashed += slash.Amount
+		}
+	}
+	report.Agreement = ratio(float64(copycat.Agreements), float64(copycat.Votes)) * 100
+	report.NormalAgreement = ratio(float64(normal.Agreements), float64(normal.Votes)) * 100
+	report.Bans = ratio(float64(copycat.Bans), float64(report.Members))
+	report.NormalBans = ratio(float64(normal.Bans), float64(normalCount))
+	return report
+}
+
+func analyzeVotes(system *System) *VoteReport {
+	report := &VoteReport{Equivocations: len(system.Equivocations)}
+	for _, fractalID := range slices.Sorted(maps.Keys(system.Fractals)) {
+		fractal := system.Fractals[fractalID]
+		for _, certificate := range fractal.Certificates {
+			report.Certificates++
+			report.Votes += len(certificate.Votes)
+			if certificate.Verify(fractal.VerificationTeam, fractal.Weights(), system.publicKey) != nil {
+				report.InvalidCertificates++
+			}
+		}
+	}
+	if report.Certificates == 0 && report.Equivocations == 0 {
+		return nil
+	}
+	return report
+}
+
+func analyzeCoalition(system *System) *CoalitionReport {
+	totalSeats, majorities := 0, 0
+	coalitionProposals, coalitionAccepted := 0, 0
+	report := &CoalitionReport{Members: len(system.Coalition.Members)}
+	for _, proposal := range system.Proposals {
+		totalSeats += proposal.CoalitionSeats
+		if proposal.CoalitionSeats > report.MaxSeats {
+			report.MaxSeats = proposal.CoalitionSeats
+		}
+		if 2*proposal.CoalitionSeats > proposal.TeamSize {
+			majorities++
+		}
+		if system.Coalition.Contains(proposal.Proposer) {
+			coalitionProposals++
+			if proposal.Accepted {
+				coalitionAccepted++
+			}
+		}
+	}
+
+	numProposals := len(system.Proposals)
+	report.AverageSeats = ratio(float64(totalSeats), float64(numProposals))
+	report.MajorityTeams = ratio(float64(majorities), float64(numProposals)) * 100
+	report.AcceptRate = ratio(float64(coalitionAccepted), float64(coalitionProposals)) * 100
+	return report
+}
+
+func analyzeSybil(system *System) []SybilPhaseReport {
+	phases := system.SybilAttack.Phases
+	reports := make([]SybilPhaseReport, 0, len(phases))
+	for index, phase := range phases {
+		end := int64(-1)
+		if index+1 < len(phases) {
+			end = phases[index+1].Time
+		}
+
+		proposals, captures, seats, badAccepts := 0, 0, 0, 0
+		coinsCount, coinsTotal := 0, 0.
+		for _, proposal := range system.Proposals {
+			if proposal.Time < phase.Time || (end >= 0 && proposal.Time >= end) {
+				continue
+			}
+
+			proposals++
+			seats += proposal.CoalitionSeats
+			if 2*proposal.CoalitionSeats > proposal.TeamSize {
+				captures++
+			}
+			if proposal.Accepted && !proposal.IsValid {
+				badAccepts++
+			}
+			if fractal, ok := system.Fractals[proposal.FractalID]; ok && proposal.Accepted {
+				for _, ring := range fractal.CooperationRings {
+					if ring.Rounds != -1 {
+						satisfaction := float64(ring.Rounds) / float64(system.Params.RoundsCount)
+						if !ring.IsValid {
+							satisfaction *= -1
+						}
+						coinsCount += len(ring.CoinIDs)
+						coinsTotal += satisfaction * float64(len(ring.CoinIDs))
+					}
+				}
 			}
 		}
-		fmt.Println("Maximum cooperation ring count:", maxRings)
+
+		reports = append(reports, SybilPhaseReport{
+			Time:                 phase.Time,
+			SybilFraction:        ratio(float64(phase.Sybils), float64(phase.Traders)) * 100,
+			Fractals:             proposals,
+			TeamCapture:          ratio(float64(captures), float64(proposals)) * 100,
+			AverageSeats:         ratio(float64(seats), float64(proposals)),
+			InvalidAcceptFractal: badAccepts,
+			CoinSatisfaction:     ratio(coinsTotal, float64(coinsCount)) * 100,
+		})
+	}
+	return reports
+}
+
+func analyzeChurn(system *System) *ChurnReport {
+	report := &ChurnReport{
+		Joined:   len(system.Joined),
+		Retired:  len(system.Retired),
+		CutRings: system.CutRings,
+	}
+	for _, coin := range system.Coins {
+		if coin.Status == pkg.Withdrawn {
+			report.WithdrawnCoins++
+		}
+	}
+	return report
+}
+
+func analyzeNetwork(system *System) *NetworkReport {
+	report := &NetworkReport{NetworkStats: system.Network.Stats}
+	for _, trader := range system.Traders {
+		if trader.Data != nil {
+			report.Conflicts += trader.Data.Conflicts
+		}
+	}
+	return report
+}
+
+func analyzeLedger(system *System) *LedgerReport {
+	report := &LedgerReport{}
+	var reference *pkg.Trader
+	for _, traderID := range system.traderIDs {
+		trader := system.Traders[traderID]
+		if trader.Data == nil || trader.Data.Ledger == nil {
+			continue
+		}
+		if err := trader.CheckLedger(); err != nil {
+			report.Mismatched++
+		}
+		if reference == nil {
+			reference = trader
+			report.Reference, report.Entries = trader.ID, trader.Data.Ledger.Entries()
+			continue
+		}
+
+		divergences := pkg.CompareLedgers(reference.Data.Ledger, trader.Data.Ledger)
+		if len(divergences) == 0 {
+			continue
+		}
+		report.Diverging++
+		if report.Divergence == nil && divergences[0].Verify() == nil {
+			report.Diverged, report.Divergence = trader.ID, &divergences[0]
+		}
+	}
+	return report
+}
+
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
 	}
+	return numerator / denominator
 }
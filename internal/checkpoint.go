@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+type Checkpoint struct {
+	Dir      string `json:"dir"`
+	Interval int64  `json:"interval"`
+	Coins    int    `json:"coins"`
+	Fractals int    `json:"fractals"`
+	Keep     int    `json:"keep"`
+
+	Count        int   `json:"count"`
+	LastTime     int64 `json:"last_time"`
+	LastCoins    int   `json:"last_coins"`
+	LastFractals int   `json:"last_fractals"`
+}
+
+func (system *System) EnableCheckpoints(checkpoint Checkpoint) error {
+	if err := os.MkdirAll(checkpoint.Dir, 0755); err != nil {
+		return err
+	}
+	checkpoint.LastTime, checkpoint.LastCoins, checkpoint.LastFractals = system.Scheduler.Clock, len(system.Coins), system.FractalCounter
+	system.Checkpoint = &checkpoint
+	return nil
+}
+
+func (system *System) checkpoint() error {
+	checkpoint := system.Checkpoint
+	if checkpoint == nil {
+		return nil
+	}
+
+	due := checkpoint.Interval > 0 && system.Scheduler.Clock-checkpoint.LastTime >= checkpoint.Interval
+	due = due || (checkpoint.Coins > 0 && len(system.Coins)-checkpoint.LastCoins >= checkpoint.Coins)
+	due = due || (checkpoint.Fractals > 0 && system.FractalCounter-checkpoint.LastFractals >= checkpoint.Fractals)
+	if !due {
+		return nil
+	}
+
+	checkpoint.Count++
+	checkpoint.LastTime, checkpoint.LastCoins, checkpoint.LastFractals = system.Scheduler.Clock, len(system.Coins), system.FractalCounter
+	if err := system.Save(checkpointPath(checkpoint.Dir, checkpoint.Count)); err != nil {
+		return err
+	}
+	if system.Params.Debug {
+		log.Printf("Checkpoint %d saved at %d\n", checkpoint.Count, system.Scheduler.Clock)
+	}
+
+	if checkpoint.Keep > 0 && checkpoint.Count > checkpoint.Keep {
+		err := os.Remove(checkpointPath(checkpoint.Dir, checkpoint.Count-checkpoint.Keep))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkpointPath(dir string, count int) string {
+	return filepath.Join(dir, fmt.Sprintf("checkpoint-%06d.json", count))
+}
+
+func LoadLatestCheckpoint(dir string) (*System, string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "checkpoint-*.json"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	slices.Sort(files)
+	for i := len(files) - 1; i >= 0; i-- {
+		system, err := Load(files[i])
+		if err != nil || !system.Resumable() {
+			log.Printf("Skipping checkpoint %s: %v\n", files[i], err)
+			continue
+		}
+		return system, files[i], nil
+	}
+	return nil, "", errors.New("no checkpoint found")
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func newTestSystem(t *testing.T, numTraders int) *System {
+	t.Helper()
+	params := pkg.DefaultParams()
+	params.SignatureScheme = tools.Ed25519
+	system := NewSystem(1, params)
+	if err := system.Init(numTraders, 0, 0, 0, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	return system
+}
+
+func TestCheckpointsKeepTheNewestFiles(t *testing.T) {
+	system := newTestSystem(t, 10)
+	dir := t.TempDir()
+	if err := system.EnableCheckpoints(Checkpoint{Dir: dir, Interval: 500, Keep: 3}); err != nil {
+		t.Fatal(err)
+	}
+	system.Start(5000, 0)
+
+	count := system.Checkpoint.Count
+	if count <= 3 {
+		t.Fatalf("expected more checkpoints than are kept, got %d", count)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "checkpoint-*.json"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 3 {
+		t.Fatalf("expected 3 checkpoints to be kept, got %v", files)
+	}
+	for i, file := range files {
+		if expected := checkpointPath(dir, count-2+i); file != expected {
+			t.Fatalf("expected checkpoint %s, got %s", expected, file)
+		}
+	}
+
+	if err := os.WriteFile(files[2], []byte(`{"version": 4, "traders": {`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, file, err := LoadLatestCheckpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if file != files[1] {
+		t.Fatalf("expected the corrupt newest checkpoint to be skipped for %s, got %s", files[1], file)
+	} else if !loaded.Resumable() || loaded.Checkpoint.Count != count-1 {
+		t.Fatalf("expected a resumable system from checkpoint %d", count-1)
+	}
+
+	for _, file := range files {
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := LoadLatestCheckpoint(dir); err == nil {
+		t.Fatal("expected an error when every checkpoint is corrupt")
+	}
+}
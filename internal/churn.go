@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type Churn struct {
+	Interval int64 `json:"interval"`
+	Joins    int   `json:"joins"`
+	Leaves   int   `json:"leaves"`
+}
+
+func (system *System) AddTrader(trader *pkg.Trader) error {
+	if _, ok := system.Traders[trader.ID]; ok {
+		return errors.New("trader already exist")
+	}
+
+	if err := system.broadcast(pkg.Message{Kind: pkg.JoinMessage, Trader: announce(trader)}); err != nil {
+		return err
+	}
+	system.register(trader)
+	request := pkg.Message{Kind: pkg.JoinMessage, To: trader.ID, Trader: announce(trader)}
+	if len(system.traderIDs) > 0 {
+		request = pkg.Message{Kind: pkg.SyncMessage, To: system.traderIDs[0], Trader: announce(trader)}
+	}
+	if _, err := system.exchange(request); err != nil {
+		return err
+	}
+
+	system.Traders[trader.ID] = trader
+	system.Joined[trader.ID] = system.Scheduler.Clock
+	if system.Supply != nil {
+		system.Supply.Deposits += trader.Account
+	}
+	index, _ := slices.BinarySearch(system.traderIDs, trader.ID)
+	system.traderIDs = slices.Insert(system.traderIDs, index, trader.ID)
+	system.Scheduler.Schedule(system.Random.Int64N(system.Params.RoundLength), Event{Kind: CoinEvent, TraderID: trader.ID})
+	return nil
+}
+
+func (system *System) RetireTrader(traderID string) error {
+	if !system.isActive(traderID) {
+		return errors.New("trader not found")
+	}
+
+	if system.Supply != nil {
+		system.Supply.Retired += system.balanceOf(traderID)
+	}
+	index, _ := slices.BinarySearch(system.traderIDs, traderID)
+	system.traderIDs = slices.Delete(system.traderIDs, index, index+1)
+	system.Retired[traderID] = system.Scheduler.Clock
+	system.Transport.Unregister(traderID)
+	if err := system.broadcast(pkg.Message{Kind: pkg.LeaveMessage, Trader: announce(system.Traders[traderID])}); err != nil {
+		return err
+	}
+
+	for _, coinID := range slices.Sorted(maps.Keys(system.Coins)) {
+		if coin := system.Coins[coinID]; coin.Owner == traderID && coin.Status == pkg.Run {
+			coin.Status = pkg.Withdrawn
+			system.Coins[coinID] = coin
+			if system.Supply != nil {
+				system.Supply.Withdrawn += coin.Amount
+			}
+		}
+	}
+	return system.cutRingsOf(traderID)
+}
+
+func (system *System) cutRingsOf(traderID string) error {
+	for _, fractalID := range slices.Sorted(maps.Keys(system.Fractals)) {
+		fractal := system.Fractals[fractalID]
+		for index, ring := range fractal.CooperationRings {
+			if ring.Rounds != -1 || !system.ringHasOwner(ring, traderID) {
+				continue
+			}
+
+			ring.Rounds = fractal.Round
+			fractal.CooperationRings[index] = ring
+			money := system.Coins[ring.CoinIDs[0]].Amount * float64(fractal.Round) / float64(system.Params.RoundsCount)
+			if err := system.applyRing(ring, money, nil); err != nil {
+				return err
+			}
+			system.CutRings++
+		}
+	}
+	return nil
+}
+
+func (system *System) ringHasOwner(ring pkg.CooperationTable, traderID string) bool {
+	for _, coinID := range ring.CoinIDs {
+		if system.Coins[coinID].Owner == traderID {
+			return true
+		}
+	}
+	return false
+}
+
+func (system *System) isActive(traderID string) bool {
+	if _, ok := system.Traders[traderID]; !ok {
+		return false
+	}
+	_, retired := system.Retired[traderID]
+	return !retired
+}
+
+func (system *System) ScheduleChurn(churn Churn) {
+	if system.Churn == nil {
+		system.Scheduler.Schedule(churn.Interval, Event{Kind: ChurnEvent})
+	}
+	system.Churn = &churn
+}
+
+func (system *System) applyChurn() error {
+	churn := system.Churn
+	for i := 0; i < churn.Leaves && len(system.traderIDs) > 1; i++ {
+		traderID := system.traderIDs[system.Random.IntN(len(system.traderIDs))]
+		if err := system.RetireTrader(traderID); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < churn.Joins; i++ {
+		trader, err := system.CreateTrader(pkg.Normal, system.Random.Float64()*1000)
+		if err != nil {
+			return err
+		}
+		if err := system.AddTrader(trader); err != nil {
+			return err
+		}
+	}
+
+	if system.Params.Debug {
+		log.Printf("Churn at %d: %d traders joined, %d traders retired\n", system.Scheduler.Clock, churn.Joins, churn.Leaves)
+	}
+	system.Scheduler.Schedule(churn.Interval, Event{Kind: ChurnEvent})
+	return nil
+}
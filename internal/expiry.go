@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"errors"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func (system *System) RefundCoin(coinID string) error {
+	coin, ok := system.Coins[coinID]
+	if !ok {
+		return errors.New("coin not found")
+	} else if coin.Status != pkg.Run || !system.isActive(coin.Owner) {
+		return nil
+	}
+
+	coin.Status = pkg.Refunded
+	system.Coins[coinID] = coin
+	if system.Supply != nil {
+		system.Supply.Refunded += coin.Amount
+	}
+	return system.broadcast(pkg.Message{Kind: pkg.RefundMessage, From: coin.Owner, Coin: &coin, Time: system.Scheduler.Clock})
+}
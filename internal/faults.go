@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type Distribution struct {
+	Kind   string  `json:"kind"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+type LinkSpec struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Latency Distribution `json:"latency"`
+}
+
+type Partition struct {
+	Start  int64 `json:"start"`
+	End    int64 `json:"end"`
+	Groups int   `json:"groups"`
+}
+
+type NetworkFaults struct {
+	Latency     Distribution `json:"latency"`
+	LinkSpread  float64      `json:"link_spread"`
+	Links       []LinkSpec   `json:"links"`
+	Loss        float64      `json:"loss"`
+	Duplicate   float64      `json:"duplicate"`
+	Reorder     float64      `json:"reorder"`
+	VoteTimeout int64        `json:"vote_timeout"`
+	Partitions  []Partition  `json:"partitions"`
+
+	Stats       NetworkStats `json:"stats"`
+	RandomState []byte       `json:"random_state,omitempty"`
+
+	random *tools.Random
+}
+
+type NetworkStats struct {
+	Sent        int `json:"sent"`
+	Delivered   int `json:"delivered"`
+	Delayed     int `json:"delayed"`
+	Lost        int `json:"lost"`
+	Partitioned int `json:"partitioned"`
+	Duplicated  int `json:"duplicated"`
+	Reordered   int `json:"reordered"`
+	TimedOut    int `json:"timed_out"`
+	Undelivered int `json:"undelivered"`
+	Errors      int `json:"errors"`
+}
+
+func LoadNetworkFaults(filePath string) (NetworkFaults, error) {
+	var faults NetworkFaults
+	file, err := os.Open(filePath)
+	if err != nil {
+		return faults, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&faults); err != nil {
+		return faults, err
+	}
+	return faults, faults.Validate()
+}
+
+func (faults NetworkFaults) Validate() error {
+	if faults.Loss < 0 || faults.Loss > 1 || faults.Duplicate < 0 || faults.Duplicate > 1 || faults.Reorder < 0 || faults.Reorder > 1 {
+		return errors.New("loss, duplicate and reorder must be between 0 and 1")
+	} else if faults.LinkSpread < 0 || faults.LinkSpread > 1 {
+		return errors.New("link spread must be between 0 and 1")
+	} else if faults.VoteTimeout < 0 {
+		return errors.New("vote timeout must be non-negative")
+	}
+
+	distributions := []Distribution{faults.Latency}
+	for _, link := range faults.Links {
+		distributions = append(distributions, link.Latency)
+	}
+	for _, distribution := range distributions {
+		if err := distribution.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, partition := range faults.Partitions {
+		if partition.Groups < 2 || partition.End <= partition.Start {
+			return errors.New("partitions need at least two groups and a positive duration")
+		}
+	}
+	return nil
+}
+
+func (d Distribution) Validate() error {
+	switch d.Kind {
+	case "", "fixed", "exponential":
+	case "uniform":
+		if d.Max < d.Min {
+			return errors.New("uniform latency needs min <= max")
+		}
+	case "normal":
+		if d.StdDev < 0 {
+			return errors.New("normal latency needs a non-negative stddev")
+		}
+	default:
+		return errors.New("unknown latency distribution")
+	}
+	if d.Mean < 0 || d.Min < 0 {
+		return errors.New("latency must be non-negative")
+	}
+	return nil
+}
+
+func (d Distribution) Sample(random *tools.Random, scale float64) int64 {
+	var value float64
+	switch d.Kind {
+	case "uniform":
+		value = d.Min + random.Float64()*(d.Max-d.Min)
+	case "exponential":
+		value = d.Min + random.ExpFloat64()*d.Mean
+	case "normal":
+		value = d.Mean + random.NormFloat64()*d.StdDev
+	default:
+		value = d.Mean
+	}
+	return int64(math.Round(math.Max(value*scale, 0)))
+}
+
+func (system *System) EnableNetworkFaults(faults NetworkFaults) {
+	if system.Seed != 0 {
+		faults.random = tools.NewRandom(system.Seed ^ uint64(tools.SHA256Int("network")))
+	} else {
+		faults.random = system.Random.Fork()
+	}
+	system.Network = &faults
+	system.UseTransport(pkg.NewLocalTransport())
+}
+
+type SimulatedTransport struct {
+	pkg.Transport
+	system *System
+}
+
+func (t *SimulatedTransport) Send(message pkg.Message) error {
+	faults := t.system.Network
+	switch message.Kind {
+	case pkg.CoinMessage, pkg.FractalMessage, pkg.PayoutMessage:
+		faults.Stats.Sent++
+		return t.gossip(message, true)
+	case pkg.VerifyMessage, pkg.RoundMessage:
+		faults.Stats.Sent++
+		return t.vote(message)
+	}
+	return t.Transport.Send(message)
+}
+
+func (t *SimulatedTransport) gossip(message pkg.Message, first bool) error {
+	faults := t.system.Network
+	if t.dropped(message) {
+		return nil
+	}
+
+	latency := t.latency(message)
+	if faults.random.Float64() < faults.Reorder {
+		faults.Stats.Reordered++
+		latency += t.latency(message)
+	}
+	if first && faults.random.Float64() < faults.Duplicate {
+		faults.Stats.Duplicated++
+		if err := t.gossip(message, false); err != nil {
+			return err
+		}
+	}
+
+	if latency == 0 {
+		faults.Stats.Delivered++
+		return t.Transport.Send(message)
+	}
+	faults.Stats.Delayed++
+	t.system.Scheduler.Schedule(latency, Event{Kind: NetworkEvent, Message: &message})
+	return nil
+}
+
+func (t *SimulatedTransport) vote(message pkg.Message) error {
+	faults := t.system.Network
+	request := message
+	request.From = message.Fractal.Proposer
+	reply := pkg.Message{From: request.To, To: request.From}
+	if t.dropped(request) || t.dropped(reply) {
+		return nil
+	} else if faults.VoteTimeout > 0 && t.latency(request)+t.latency(reply) > faults.VoteTimeout {
+		faults.Stats.TimedOut++
+		return nil
+	}
+	faults.Stats.Delivered++
+	return t.Transport.Send(message)
+}
+
+func (t *SimulatedTransport) dropped(message pkg.Message) bool {
+	faults := t.system.Network
+	if t.partitioned(message.From, message.To) {
+		faults.Stats.Partitioned++
+		return true
+	} else if faults.random.Float64() < faults.Loss {
+		faults.Stats.Lost++
+		return true
+	}
+	return false
+}
+
+func (t *SimulatedTransport) latency(message pkg.Message) int64 {
+	faults := t.system.Network
+	for _, link := range faults.Links {
+		if link.From == message.From && link.To == message.To {
+			return link.Latency.Sample(faults.random, 1)
+		}
+	}
+
+	scale := 1.0
+	if faults.LinkSpread > 0 {
+		position := float64(uint64(tools.SHA256Int(message.From+"-"+message.To))%1000) / 999
+		scale += faults.LinkSpread * (2*position - 1)
+	}
+	return faults.Latency.Sample(faults.random, scale)
+}
+
+func (t *SimulatedTransport) partitioned(from, to string) bool {
+	if from == pkg.SystemID || to == pkg.SystemID {
+		return false
+	}
+	for _, partition := range t.system.Network.Partitions {
+		if t.system.Scheduler.Clock >= partition.Start && t.system.Scheduler.Clock < partition.End {
+			if partitionGroup(from, partition.Groups) != partitionGroup(to, partition.Groups) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func partitionGroup(traderID string, groups int) int {
+	return int(uint64(tools.SHA256Int(traderID)) % uint64(groups))
+}
+
+func (system *System) deliver(message pkg.Message) error {
+	if t, ok := system.Transport.(*SimulatedTransport); ok {
+		if err := t.Transport.Send(message); err != nil {
+			system.Network.Stats.Undelivered++
+			return nil
+		}
+		system.Network.Stats.Delivered++
+	}
+	if _, err := system.exchange(); err != nil {
+		system.Network.Stats.Errors++
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func BindParams(flags *flag.FlagSet, withAlpha bool) func(base pkg.Params) (pkg.Params, error) {
+	defaults, values := pkg.DefaultParams(), pkg.DefaultParams()
+	configPtr := flags.String("config", "", "JSON file of protocol parameters (flags override it)")
+	flags.IntVar(&values.FractalMin, "fractal-min", defaults.FractalMin, "minimum number of cooperation rings in a fractal ring")
+	flags.IntVar(&values.FractalMax, "fractal-max", defaults.FractalMax, "maximum number of cooperation rings in a fractal ring")
+	flags.Float64Var(&values.FractalPrize, "fractal-prize", defaults.FractalPrize, "prize paid to each coin of a finished cooperation ring")
+	flags.IntVar(&values.RoundsCount, "rounds", defaults.RoundsCount, "number of rounds of a fractal ring")
+	flags.Int64Var(&values.RoundLength, "round-length", defaults.RoundLength, "virtual ticks per round")
+	flags.IntVar(&values.VerificationMin, "team-min", defaults.VerificationMin, "minimum verification team size")
+	flags.IntVar(&values.VerificationMax, "team-max", defaults.VerificationMax, "maximum verification team size")
+	flags.IntVar(&values.BanCount, "ban", defaults.BanCount, "number of fractal rings a trader is banned for")
+	flags.BoolVar(&values.BanMinority, "ban-minority", defaults.BanMinority, "ban the traders that voted against the outcome")
+	flags.Float64Var(&values.SlashEquivocation, "slash-equivocation", defaults.SlashEquivocation, "amount slashed from a trader for a double vote")
+	flags.Float64Var(&values.SlashInvalidProposal, "slash-proposal", defaults.SlashInvalidProposal, "amount slashed from a trader for proposing an invalid fractal ring")
+	flags.Float64Var(&values.SlashInvalidApproval, "slash-approval", defaults.SlashInvalidApproval, "amount slashed from a trader for approving an invalid fractal ring")
+	flags.Float64Var(&values.SlashNoReveal, "slash-no-reveal", defaults.SlashNoReveal, "amount slashed from a trader for not revealing a committed vote")
+	flags.Float64Var(&values.SlashDoubleSpend, "slash-double-spend", defaults.SlashDoubleSpend, "amount slashed from a trader for signing two coins with the same sequence")
+	flags.IntVar(&values.ResendAfter, "resend-after", defaults.ResendAfter, "coins buffered behind a sequence gap before asking their owner to resend the missing coin (0 to never ask)")
+	flags.Int64Var(&values.CoinTTL, "coin-ttl", defaults.CoinTTL, "virtual ticks (milliseconds for nodes) before an unmatched coin is refunded (0 to never refund)")
+	flags.BoolVar(&values.CommitReveal, "commit-reveal", defaults.CommitReveal, "commit to votes by hash before revealing them")
+	flags.BoolVar(&values.Ledger, "ledger", defaults.Ledger, "keep a hash-chained ledger of every balance change in each view")
+	flags.BoolVar(&values.ReputationTeams, "reputation-teams", defaults.ReputationTeams, "weight verification team members by their reputation")
+	flags.Func("voting", "weight of a vote: count (one per trader), account or stake (blocked coins) (default count)", func(value string) error {
+		values.VotingRule = pkg.VotingRule(value)
+		return nil
+	})
+	flags.Func("quorum", "share of vote weight needed to accept: majority, bft (2/3) or a fraction (default majority)", func(value string) (err error) {
+		values.Quorum, err = parseQuorum(value)
+		return
+	})
+	flags.Func("signature", "signature scheme of trader keys: rsa-pss or ed25519 (default rsa-pss)", func(value string) error {
+		values.SignatureScheme = tools.SignatureScheme(value)
+		return nil
+	})
+	flags.IntVar(&values.KeySize, "key-size", defaults.KeySize, "RSA key size in bits (rsa-pss only)")
+	flags.Func("id-encoding", "encoding of coin IDs and signatures: hex or base64 (default hex)", func(value string) error {
+		values.IDEncoding = tools.IDEncoding(value)
+		return nil
+	})
+	flags.BoolVar(&values.CheckInvariants, "check-invariants", defaults.CheckInvariants, "check money conservation and view consistency after every fractal ring and stop on a violation")
+	flags.BoolVar(&values.Debug, "debug", defaults.Debug, "print debug logs")
+	flags.BoolVar(&values.RunFractals, "run-fractals", defaults.RunFractals, "run the rounds of accepted fractal rings")
+	if withAlpha {
+		flags.Float64Var(&values.BadBehavior, "alpha", defaults.BadBehavior, "bad behavior percentage")
+	}
+
+	return func(base pkg.Params) (pkg.Params, error) {
+		params := base
+		if *configPtr != "" {
+			p, err := pkg.LoadParams(*configPtr)
+			if err != nil {
+				return params, err
+			}
+			params = p
+		}
+
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "fractal-min":
+				params.FractalMin = values.FractalMin
+			case "fractal-max":
+				params.FractalMax = values.FractalMax
+			case "fractal-prize":
+				params.FractalPrize = values.FractalPrize
+			case "rounds":
+				params.RoundsCount = values.RoundsCount
+			case "round-length":
+				params.RoundLength = values.RoundLength
+			case "team-min":
+				params.VerificationMin = values.VerificationMin
+			case "team-max":
+				params.VerificationMax = values.VerificationMax
+			case "ban":
+				params.BanCount = values.BanCount
+			case "ban-minority":
+				params.BanMinority = values.BanMinority
+			case "slash-equivocation":
+				params.SlashEquivocation = values.SlashEquivocation
+			case "slash-proposal":
+				params.SlashInvalidProposal = values.SlashInvalidProposal
+			case "slash-approval":
+				params.SlashInvalidApproval = values.SlashInvalidApproval
+			case "slash-no-reveal":
+				params.SlashNoReveal = values.SlashNoReveal
+			case "slash-double-spend":
+				params.SlashDoubleSpend = values.SlashDoubleSpend
+			case "coin-ttl":
+				params.CoinTTL = values.CoinTTL
+			case "resend-after":
+				params.ResendAfter = values.ResendAfter
+			case "commit-reveal":
+				params.CommitReveal = values.CommitReveal
+			case "ledger":
+				params.Ledger = values.Ledger
+			case "reputation-teams":
+				params.ReputationTeams = values.ReputationTeams
+			case "voting":
+				params.VotingRule = values.VotingRule
+			case "quorum":
+				params.Quorum = values.Quorum
+			case "signature":
+				params.SignatureScheme = values.SignatureScheme
+			case "key-size":
+				params.KeySize = values.KeySize
+			case "id-encoding":
+				params.IDEncoding = values.IDEncoding
+			case "check-invariants":
+				params.CheckInvariants = values.CheckInvariants
+			case "debug":
+				params.Debug = values.Debug
+			case "run-fractals":
+				params.RunFractals = values.RunFractals
+			case "alpha":
+				if withAlpha {
+					params.BadBehavior = values.BadBehavior
+				}
+			}
+		})
+		return params, params.Validate()
+	}
+}
+
+func parseQuorum(value string) (float64, error) {
+	switch value {
+	case "majority":
+		return pkg.MajorityQuorum, nil
+	case "bft":
+		return pkg.BFTQuorum, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+const invariantTolerance = 1e-6
+
+type Supply struct {
+	Deposits  float64 `json:"deposits"`
+	Retired   float64 `json:"retired"`
+	Minted    float64 `json:"minted"`
+	Withdrawn float64 `json:"withdrawn"`
+	Refunded  float64 `json:"refunded"`
+	Settled   float64 `json:"settled"`
+	Payouts   float64 `json:"payouts"`
+	Prizes    float64 `json:"prizes"`
+	Slashed   float64 `json:"slashed"`
+	Checks    int     `json:"checks"`
+}
+
+type InvariantError struct {
+	Time     int64
+	Fractals int
+	Diff     []string
+}
+
+func (supply *Supply) Total() float64 {
+	return supply.Deposits - supply.Retired + supply.Prizes - supply.Slashed + supply.Payouts - supply.Settled - supply.Withdrawn
+}
+
+func (supply *Supply) Locked() float64 {
+	return supply.Minted - supply.Settled - supply.Withdrawn - supply.Refunded
+}
+
+func (err *InvariantError) Error() string {
+	lines := err.Diff
+	if len(lines) > 20 {
+		lines = append(slices.Clip(lines[:20]), fmt.Sprintf("... and %d more", len(err.Diff)-20))
+	}
+	return fmt.Sprintf("%d invariants broken after %d fractal rings at tick %d:\n  %s", len(err.Diff), err.Fractals, err.Time, strings.Join(lines, "\n  "))
+}
+
+func (system *System) openSupply() {
+	locked := system.lockedCoins()
+	system.Supply = &Supply{Minted: locked, Deposits: locked}
+	if reference := system.reference(); reference != nil {
+		system.Supply.Deposits += balances(reference)
+	}
+}
+
+func (system *System) checkInvariants(event Event) error {
+	if system.Supply == nil {
+		return nil
+	} else if event.Kind == RoundEvent || (event.Kind == CheckEvent && system.FractalCounter != system.checked) {
+		system.unchecked = true
+	}
+	if !system.unchecked || system.inFlight() {
+		return nil
+	}
+
+	system.unchecked, system.checked = false, system.FractalCounter
+	system.Supply.Checks++
+	return system.CheckInvariants()
+}
+
+func (system *System) inFlight() bool {
+	return slices.ContainsFunc(system.Scheduler.Events, func(event Event) bool {
+		return event.Kind == NetworkEvent
+	})
+}
+
+func (system *System) CheckInvariants() error {
+	supply := system.Supply
+	if supply == nil {
+		return nil
+	}
+
+	var diff []string
+	locked := system.lockedCoins()
+	if !same(locked, supply.Locked()) {
+		diff = append(diff, fmt.Sprintf("locked coins: %.6f minted - %.6f settled - %.6f withdrawn - %.6f refunded = %.6f, but running and blocked coins hold %.6f",
+			supply.Minted, supply.Settled, supply.Withdrawn, supply.Refunded, supply.Locked(), locked))
+	}
+	if reference := system.reference(); reference != nil {
+		held, coins := balances(reference), viewLocked(reference)
+		if !same(held+coins, supply.Total()) {
+			diff = append(diff, fmt.Sprintf("supply: %.6f deposited - %.6f retired + %.6f prizes - %.6f slashed + %.6f payouts - %.6f settled - %.6f withdrawn = %.6f, but view of %s holds %.6f (%.6f in balances, %.6f in coins)",
+				supply.Deposits, supply.Retired, supply.Prizes, supply.Slashed, supply.Payouts, supply.Settled, supply.Withdrawn, supply.Total(), reference.ID, held+coins, held, coins))
+		}
+	}
+
+	for _, trader := range system.sortedTraders() {
+		if trader.Data == nil {
+			continue
+		}
+		if coins := viewLocked(trader); !same(coins, locked) {
+			diff = append(diff, fmt.Sprintf("locked coins: view of %s holds %.6f, expected %.6f", trader.ID, coins, locked))
+		}
+		for traderID := range trader.Data.Traders {
+			if !system.isActive(traderID) {
+				diff = append(diff, fmt.Sprintf("trader %s: not active, but in view of %s", traderID, trader.ID))
+			}
+		}
+	}
+	for _, traderID := range system.traderIDs {
+		diff = append(diff, system.compareViews(traderID)...)
+	}
+
+	if len(diff) > 0 {
+		return &InvariantError{Time: system.Scheduler.Clock, Fractals: system.FractalCounter, Diff: diff}
+	}
+	return nil
+}
+
+func (system *System) compareViews(traderID string) []string {
+	viewers := make(map[string][]string)
+	for _, trader := range system.sortedTraders() {
+		if trader.Data == nil {
+			continue
+		}
+		state := "missing"
+		if record, ok := trader.Data.Traders[traderID]; ok {
+			state = fmt.Sprintf("account %.6f, sequence %d", record.Account, record.Sequence)
+			if trader.Data.Ledger != nil {
+				state += fmt.Sprintf(", ledger head %.8s", trader.Data.Ledger.Head(traderID))
+			}
+		}
+		viewers[state] = append(viewers[state], trader.ID)
+	}
+	if len(viewers) < 2 {
+		return nil
+	}
+
+	states := slices.SortedFunc(maps.Keys(viewers), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(viewers[b]), len(viewers[a])), cmp.Compare(a, b))
+	})
+	parts := []string{fmt.Sprintf("%s in %d views", states[0], len(viewers[states[0]]))}
+	for _, state := range states[1:] {
+		parts = append(parts, fmt.Sprintf("%s in views of %s", state, listIDs(viewers[state])))
+	}
+	return []string{fmt.Sprintf("trader %s: %s", traderID, strings.Join(parts, "; "))}
+}
+
+func (system *System) reference() *pkg.Trader {
+	for _, trader := range system.sortedTraders() {
+		if trader.Data != nil {
+			return trader
+		}
+	}
+	return nil
+}
+
+func (system *System) balanceOf(traderID string) float64 {
+	if trader, ok := system.Traders[traderID]; ok && trader.Data != nil {
+		return trader.Data.Traders[traderID].Account
+	}
+	return 0
+}
+
+func (system *System) lockedCoins() (locked float64) {
+	for _, coin := range system.Coins {
+		if coin.Status == pkg.Run || coin.Status == pkg.Blocked {
+			locked += coin.Amount
+		}
+	}
+	return
+}
+
+func balances(trader *pkg.Trader) (total float64) {
+	for _, record := range trader.Data.Traders {
+		total += record.Account
+	}
+	return
+}
+
+func viewLocked(trader *pkg.Trader) (locked float64) {
+	for _, coin := range trader.Data.Coins {
+		if coin.Status == pkg.Run || coin.Status == pkg.Blocked {
+			locked += coin.Amount
+		}
+	}
+	return
+}
+
+func same(a, b float64) bool {
+	return math.Abs(a-b) <= invariantTolerance*max(1, math.Abs(a), math.Abs(b))
+}
+
+func listIDs(ids []string) string {
+	if len(ids) > 3 {
+		return fmt.Sprintf("%s and %d more", strings.Join(ids[:3], ", "), len(ids)-3)
+	}
+	return strings.Join(ids, ", ")
+}
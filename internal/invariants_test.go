@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func checkTestInvariants(t *testing.T, system *System) []string {
+	t.Helper()
+	err := system.CheckInvariants()
+	if err == nil {
+		return nil
+	}
+	var broken *InvariantError
+	if !errors.As(err, &broken) {
+		t.Fatal(err)
+	}
+	return broken.Diff
+}
+
+func TestInvariantsFlagBrokenBalances(t *testing.T) {
+	system := newTestSystem(t, 10)
+	params := system.Params
+	params.CheckInvariants = true
+	if err := system.SetParams(params); err != nil {
+		t.Fatal(err)
+	}
+	system.Start(3000, 0)
+	if diff := checkTestInvariants(t, system); diff != nil {
+		t.Fatalf("expected the run to keep its invariants, got %v", diff)
+	} else if len(system.Coins) == 0 {
+		t.Fatal("expected the run to mint coins")
+	}
+
+	reference, victim := system.reference(), system.traderIDs[len(system.traderIDs)-1]
+	record := reference.Data.Traders[victim]
+	record.Account += 5
+	reference.Data.Traders[victim] = record
+	diff := checkTestInvariants(t, system)
+	if len(diff) != 2 || !strings.HasPrefix(diff[0], "supply: ") || !strings.HasPrefix(diff[1], "trader "+victim+": ") {
+		t.Fatalf("expected the supply and the victim's views to be flagged, got %v", diff)
+	}
+
+	for _, trader := range system.sortedTraders() {
+		if trader != reference {
+			record := trader.Data.Traders[victim]
+			record.Account += 5
+			trader.Data.Traders[victim] = record
+		}
+	}
+	diff = checkTestInvariants(t, system)
+	if len(diff) != 1 || !strings.HasPrefix(diff[0], "supply: ") {
+		t.Fatalf("expected an agreed but inflated balance to break the supply, got %v", diff)
+	}
+
+	for _, trader := range system.sortedTraders() {
+		for coinID, coin := range trader.Data.Coins {
+			coin.Amount++
+			trader.Data.Coins[coinID] = coin
+			break
+		}
+	}
+	for _, line := range checkTestInvariants(t, system) {
+		if strings.HasPrefix(line, "locked coins: view of ") {
+			return
+		}
+	}
+	t.Fatal("expected a changed coin amount to break the locked coins of the views")
+}
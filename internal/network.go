@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func (system *System) UseTransport(transport pkg.Transport) {
+	if system.Network != nil {
+		transport = &SimulatedTransport{Transport: transport, system: system}
+	}
+	if system.Transport != nil {
+		system.Transport.Close()
+	}
+	system.Transport = transport
+	system.Transport.Register(pkg.SystemID, system.receive)
+	for _, trader := range system.sortedTraders() {
+		system.register(trader)
+	}
+}
+
+func (system *System) register(trader *pkg.Trader) {
+	system.Transport.Register(trader.ID, pkg.NewNode(trader, system.Transport).Handle)
+}
+
+func (system *System) receive(message pkg.Message) {
+	system.inboxLocker.Lock()
+	defer system.inboxLocker.Unlock()
+	system.inbox = append(system.inbox, message)
+}
+
+func (system *System) exchange(messages ...pkg.Message) ([]pkg.Message, error) {
+	var err error
+	for _, message := range messages {
+		if message.From == "" {
+			message.From = pkg.SystemID
+		}
+		if e := system.Transport.Send(message); e != nil && err == nil {
+			err = e
+		}
+	}
+	system.Transport.Flush()
+
+	system.inboxLocker.Lock()
+	inbox := system.inbox
+	system.inbox = nil
+	system.inboxLocker.Unlock()
+
+	slices.SortStableFunc(inbox, func(a, b pkg.Message) int {
+		return cmp.Compare(a.From, b.From)
+	})
+	replies := make([]pkg.Message, 0, len(inbox))
+	for _, message := range inbox {
+		if message.Kind != pkg.ErrorMessage {
+			replies = append(replies, message)
+		} else if err == nil {
+			err = errors.New(message.Error)
+		}
+	}
+	return replies, err
+}
+
+func (system *System) broadcast(message pkg.Message) error {
+	messages := make([]pkg.Message, 0, len(system.traderIDs))
+	for _, traderID := range system.traderIDs {
+		message.To = traderID
+		messages = append(messages, message)
+	}
+	_, err := system.exchange(messages...)
+	return err
+}
+
+func repliesFrom(replies []pkg.Message) map[string]pkg.Message {
+	result := make(map[string]pkg.Message)
+	for _, reply := range replies {
+		result[reply.From] = reply
+	}
+	return result
+}
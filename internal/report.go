@@ -0,0 +1,469 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type Report struct {
+	Coins                int     `json:"coins"`
+	Fractals             int     `json:"fractals"`
+	RunCoins             int     `json:"run_coins"`
+	SubmitFractal        float64 `json:"submit_fractal"`
+	AcceptFractal        float64 `json:"accept_fractal"`
+	InvalidAcceptFractal int     `json:"invalid_accept_fractal"`
+	ValidRejectFractal   int     `json:"valid_reject_fractal"`
+	CoinSatisfaction     float64 `json:"coin_satisfaction"`
+	TraderSatisfaction   float64 `json:"trader_satisfaction"`
+	AverageAdjacency     float64 `json:"average_adjacency"`
+	MaxAdjacency         int     `json:"max_adjacency"`
+	MaxCooperation       int     `json:"max_cooperation"`
+
+	Coalition   *CoalitionReport   `json:"coalition,omitempty"`
+	SybilPhases []SybilPhaseReport `json:"sybil_phases,omitempty"`
+	Churn       *ChurnReport       `json:"churn,omitempty"`
+	Network     *NetworkReport     `json:"network,omitempty"`
+	Votes       *VoteReport        `json:"votes,omitempty"`
+	Slashing    *SlashReport       `json:"slashing,omitempty"`
+	Reputation  *ReputationReport  `json:"reputation,omitempty"`
+	Voting      *VotingReport      `json:"voting,omitempty"`
+	Copycat     *CopycatReport     `json:"copycat,omitempty"`
+	Ledger      *LedgerReport      `json:"ledger,omitempty"`
+	Supply      *Supply            `json:"supply,omitempty"`
+	Matching    *MatchingReport    `json:"matching,omitempty"`
+
+	RunFractals bool `json:"-"`
+}
+
+type CoalitionReport struct {
+	Members       int     `json:"members"`
+	AverageSeats  float64 `json:"average_seats"`
+	MaxSeats      int     `json:"max_seats"`
+	MajorityTeams float64 `json:"majority_teams"`
+	AcceptRate    float64 `json:"accept_rate"`
+}
+
+type SybilPhaseReport struct {
+	Time                 int64   `json:"time"`
+	SybilFraction        float64 `json:"sybil_fraction"`
+	Fractals             int     `json:"fractals"`
+	TeamCapture          float64 `json:"team_capture"`
+	AverageSeats         float64 `json:"average_seats"`
+	InvalidAcceptFractal int     `json:"invalid_accept_fractal"`
+	CoinSatisfaction     float64 `json:"coin_satisfaction"`
+}
+
+type ChurnReport struct {
+	Joined         int `json:"joined"`
+	Retired        int `json:"retired"`
+	WithdrawnCoins int `json:"withdrawn_coins"`
+	CutRings       int `json:"cut_rings"`
+}
+
+type NetworkReport struct {
+	NetworkStats
+	Conflicts int `json:"conflicts"`
+}
+
+type VoteReport struct {
+	Certificates        int `json:"certificates"`
+	InvalidCertificates int `json:"invalid_certificates"`
+	Votes               int `json:"votes"`
+	Equivocations       int `json:"equivocations"`
+}
+
+type SlashReport struct {
+	MinorityVotes int                   `json:"minority_votes"`
+	Behaviors     []BehaviorSlashReport `json:"behaviors"`
+}
+
+type BehaviorSlashReport struct {
+	Behavior         string  `json:"behavior"`
+	Equivocations    int     `json:"equivocations"`
+	InvalidProposals int     `json:"invalid_proposals"`
+	InvalidApprovals int     `json:"invalid_approvals"`
+	Unrevealed       int     `json:"unrevealed"`
+	Slashed          float64 `json:"slashed"`
+}
+
+type ReputationReport struct {
+	BadTraders  float64                 `json:"bad_traders"`
+	NormalScore float64                 `json:"normal_score"`
+	BadScore    float64                 `json:"bad_score"`
+	Phases      []ReputationPhaseReport `json:"phases"`
+}
+
+type ReputationPhaseReport struct {
+	Time     int64   `json:"time"`
+	Fractals int     `json:"fractals"`
+	BadSeats float64 `json:"bad_seats"`
+}
+
+type VotingReport struct {
+	Rule                 string  `json:"rule"`
+	Quorum               float64 `json:"quorum"`
+	Verifications        int     `json:"verifications"`
+	VerificationFlips    int     `json:"verification_flips"`
+	InvalidAccepted      int     `json:"invalid_accepted"`
+	CountInvalidAccepted int     `json:"count_invalid_accepted"`
+	ValidRejected        int     `json:"valid_rejected"`
+	CountValidRejected   int     `json:"count_valid_rejected"`
+	Rounds               int     `json:"rounds"`
+	RoundFlips           int     `json:"round_flips"`
+}
+
+type CopycatReport struct {
+	Members         int     `json:"members"`
+	CommitReveal    bool    `json:"commit_reveal"`
+	Agreement       float64 `json:"agreement"`
+	NormalAgreement float64 `json:"normal_agreement"`
+	Bans            float64 `json:"bans"`
+	NormalBans      float64 `json:"normal_bans"`
+	Unrevealed      int     `json:"unrevealed"`
+	Slashed         float64 `json:"slashed"`
+}
+
+type LedgerReport struct {
+	Reference  string          `json:"reference"`
+	Entries    int             `json:"entries"`
+	Mismatched int             `json:"mismatched"`
+	Diverging  int             `json:"diverging"`
+	Diverged   string          `json:"diverged,omitempty"`
+	Divergence *pkg.Divergence `json:"divergence,omitempty"`
+}
+
+type MatchingReport struct {
+	Matched        int                 `json:"matched"`
+	Refunded       int                 `json:"refunded"`
+	RefundedAmount float64             `json:"refunded_amount"`
+	Running        int                 `json:"running"`
+	Latency        LatencyReport       `json:"latency"`
+	CoinTypes      []CoinLatencyReport `json:"coin_types"`
+}
+
+type LatencyReport struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   int64   `json:"p50"`
+	P90   int64   `json:"p90"`
+	P99   int64   `json:"p99"`
+	Max   int64   `json:"max"`
+}
+
+type CoinLatencyReport struct {
+	Type uint `json:"type"`
+	LatencyReport
+}
+
+func (report Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		return report.WriteText(w)
+	case "json":
+		return report.WriteJSON(w)
+	case "csv":
+		return report.WriteCSV(w)
+	}
+	return errors.New("unknown report format")
+}
+
+func (report Report) WriteText(w io.Writer) error {
+	lines := []string{
+		fmt.Sprintln("Number of coins:", report.Coins),
+		fmt.Sprintln("Number of fractal rings:", report.Fractals),
+		fmt.Sprintln("Number of run coins:", report.RunCoins),
+		fmt.Sprintf("Average number of submitted fractal rings per trader: %.2f\n", report.SubmitFractal),
+		fmt.Sprintf("Average fractal ring acceptance rate per trader: %.2f%%\n", report.AcceptFractal),
+		fmt.Sprintln("Number of invalid accepted fractal rings:", report.InvalidAcceptFractal),
+		fmt.Sprintln("Number of valid rejected fractal rings:", report.ValidRejectFractal),
+	}
+	if report.RunFractals {
+		lines = append(lines,
+			fmt.Sprintf("Average satisfaction per coin: %.2f%%\n", report.CoinSatisfaction),
+			fmt.Sprintf("Average satisfaction per trader: %.2f%%\n", report.TraderSatisfaction),
+			fmt.Sprintf("Average adjacency per trader: %.2f\n", report.AverageAdjacency),
+			fmt.Sprintln("Maximum adjacency per trader:", report.MaxAdjacency),
+			fmt.Sprintln("Maximum cooperation ring count:", report.MaxCooperation),
+		)
+	}
+
+	if coalition := report.Coalition; coalition != nil {
+		lines = append(lines,
+			fmt.Sprintln("Number of colluding traders:", coalition.Members),
+			fmt.Sprintf("Average coalition seats per verification team: %.2f\n", coalition.AverageSeats),
+			fmt.Sprintln("Maximum coalition seats per verification team:", coalition.MaxSeats),
+			fmt.Sprintf("Verification teams with coalition majority: %.2f%%\n", coalition.MajorityTeams),
+			fmt.Sprintf("Coalition fractal ring acceptance rate: %.2f%%\n", coalition.AcceptRate),
+		)
+	}
+	for index, phase := range report.SybilPhases {
+		lines = append(lines, fmt.Sprintf("Sybil phase %d (time %d, sybil fraction %.2f%%): %d fractal rings proposed, team capture %.2f%%, average coalition seats %.2f, invalid accepted fractal rings %d, coin satisfaction %.2f%%\n",
+			index, phase.Time, phase.SybilFraction, phase.Fractals, phase.TeamCapture, phase.AverageSeats, phase.InvalidAcceptFractal, phase.CoinSatisfaction))
+	}
+	if churn := report.Churn; churn != nil {
+		lines = append(lines,
+			fmt.Sprintln("Number of joined traders:", churn.Joined),
+			fmt.Sprintln("Number of retired traders:", churn.Retired),
+			fmt.Sprintln("Number of withdrawn coins:", churn.WithdrawnCoins),
+			fmt.Sprintln("Number of cooperation rings cut by departures:", churn.CutRings),
+		)
+	}
+	if network := report.Network; network != nil {
+		lines = append(lines,
+			fmt.Sprintf("Network messages: %d sent, %d delivered, %d delayed, %d lost, %d partitioned, %d duplicated, %d reordered, %d timed out, %d undelivered\n",
+				network.Sent, network.Delivered, network.Delayed, network.Lost, network.Partitioned, network.Duplicated, network.Reordered, network.TimedOut, network.Undelivered),
+			fmt.Sprintln("Number of errors from late messages:", network.Errors),
+			fmt.Sprintln("Number of stale cooperation ring conflicts:", network.Conflicts),
+		)
+	}
+	if votes := report.Votes; votes != nil {
+		lines = append(lines,
+			fmt.Sprintf("Number of vote certificates: %d (%d invalid, %d signed votes)\n", votes.Certificates, votes.InvalidCertificates, votes.Votes),
+			fmt.Sprintln("Number of equivocations:", votes.Equivocations),
+		)
+	}
+	if slashing := report.Slashing; slashing != nil {
+		lines = append(lines, fmt.Sprintln("Number of minority votes (not slashed):", slashing.MinorityVotes))
+		for _, behavior := range slashing.Behaviors {
+			lines = append(lines, fmt.Sprintf("Slashing of %s traders: %d equivocations, %d invalid proposals, %d invalid approvals, %d unrevealed votes, %.2f slashed\n",
+				behavior.Behavior, behavior.Equivocations, behavior.InvalidProposals, behavior.InvalidApprovals, behavior.Unrevealed, behavior.Slashed))
+		}
+	}
+	if reputation := report.Reputation; reputation != nil {
+		lines = append(lines, fmt.Sprintf("Misbehaving traders: %.2f%% (average reputation %.3f, normal traders %.3f)\n", reputation.BadTraders, reputation.BadScore, reputation.NormalScore))
+		for index, phase := range reputation.Phases {
+			lines = append(lines, fmt.Sprintf("Reputation phase %d (time %d): %d fractal rings proposed, %.2f%% of verification team seats held by misbehaving traders\n",
+				index, phase.Time, phase.Fractals, phase.BadSeats))
+		}
+	}
+	if voting := report.Voting; voting != nil {
+		lines = append(lines,
+			fmt.Sprintf("Voting rule %s with quorum %.2f: %d of %d verification and %d of %d round decisions differ from one-trader-one-vote majority\n",
+				voting.Rule, voting.Quorum, voting.VerificationFlips, voting.Verifications, voting.RoundFlips, voting.Rounds),
+			fmt.Sprintf("Invalid fractal rings passing verification: %d (%d under one-trader-one-vote majority)\n", voting.InvalidAccepted, voting.CountInvalidAccepted),
+			fmt.Sprintf("Valid fractal rings failing verification: %d (%d under one-trader-one-vote majority)\n", voting.ValidRejected, voting.CountValidRejected),
+		)
+	}
+	if copycat := report.Copycat; copycat != nil {
+		mode := "plain voting"
+		if copycat.CommitReveal {
+			mode = "commit-reveal voting"
+		}
+		lines = append(lines,
+			fmt.Sprintf("Number of copycat traders: %d (%s)\n", copycat.Members, mode),
+			fmt.Sprintf("Votes agreeing with the outcome: %.2f%% for copycat traders, %.2f%% for normal traders\n", copycat.Agreement, copycat.NormalAgreement),
+			fmt.Sprintf("Average bans per trader: %.2f for copycat traders, %.2f for normal traders\n", copycat.Bans, copycat.NormalBans),
+			fmt.Sprintf("Unrevealed copycat votes: %d (%.2f slashed)\n", copycat.Unrevealed, copycat.Slashed),
+		)
+	}
+	if ledger := report.Ledger; ledger != nil {
+		lines = append(lines, fmt.Sprintf("Ledger entries: %d (%d views not derived from their ledger, %d ledgers diverging)\n", ledger.Entries, ledger.Mismatched, ledger.Diverging))
+		if divergence := ledger.Divergence; divergence != nil {
+			lines = append(lines, fmt.Sprintf("First divergence: account %s at entry %d between %s and %s (%s vs %s)\n",
+				divergence.Account, divergence.Index, ledger.Reference, ledger.Diverged, describeEntry(divergence.First), describeEntry(divergence.Second)))
+		}
+	}
+	if supply := report.Supply; supply != nil {
+		lines = append(lines,
+			fmt.Sprintf("Money supply: %.2f (%.2f deposited, %.2f withdrawn by retired traders, %.2f in prizes, %.2f slashed, %.2f locked in coins)\n",
+				supply.Total(), supply.Deposits, supply.Retired+supply.Withdrawn, supply.Prizes, supply.Slashed, supply.Locked()),
+			fmt.Sprintf("Cooperation ring payouts: %.2f for %.2f of settled coins (%+.2f)\n", supply.Payouts, supply.Settled, supply.Payouts-supply.Settled),
+			fmt.Sprintln("Number of passed invariant checks:", supply.Checks),
+		)
+	}
+	if matching := report.Matching; matching != nil {
+		lines = append(lines,
+			fmt.Sprintf("Coin matching: %d matched, %d refunded after expiry (%.2f returned), %d still running\n", matching.Matched, matching.Refunded, matching.RefundedAmount, matching.Running),
+			fmt.Sprintf("Matching latency: %s\n", describeLatency(matching.Latency)),
+		)
+		for _, coinType := range matching.CoinTypes {
+			lines = append(lines, fmt.Sprintf("Matching latency of coin type %d: %s\n", coinType.Type, describeLatency(coinType.LatencyReport)))
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (report Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func (report Report) WriteCSV(w io.Writer) error {
+	header, values := report.Fields()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	} else if err := writer.Write(values); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (report Report) Fields() (header []string, values []string) {
+	add := func(key string, value any) {
+		header = append(header, key)
+		switch v := value.(type) {
+		case float64:
+			values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+
+	add("coins", report.Coins)
+	add("fractals", report.Fractals)
+	add("run_coins", report.RunCoins)
+	add("submit_fractal", report.SubmitFractal)
+	add("accept_fractal", report.AcceptFractal)
+	add("invalid_accept_fractal", report.InvalidAcceptFractal)
+	add("valid_reject_fractal", report.ValidRejectFractal)
+	add("coin_satisfaction", report.CoinSatisfaction)
+	add("trader_satisfaction", report.TraderSatisfaction)
+	add("average_adjacency", report.AverageAdjacency)
+	add("max_adjacency", report.MaxAdjacency)
+	add("max_cooperation", report.MaxCooperation)
+
+	if coalition := report.Coalition; coalition != nil {
+		add("coalition_members", coalition.Members)
+		add("coalition_average_seats", coalition.AverageSeats)
+		add("coalition_max_seats", coalition.MaxSeats)
+		add("coalition_majority_teams", coalition.MajorityTeams)
+		add("coalition_accept_rate", coalition.AcceptRate)
+	}
+	for index, phase := range report.SybilPhases {
+		prefix := fmt.Sprintf("sybil_%d_", index)
+		add(prefix+"time", phase.Time)
+		add(prefix+"sybil_fraction", phase.SybilFraction)
+		add(prefix+"fractals", phase.Fractals)
+		add(prefix+"team_capture", phase.TeamCapture)
+		add(prefix+"average_seats", phase.AverageSeats)
+		add(prefix+"invalid_accept_fractal", phase.InvalidAcceptFractal)
+		add(prefix+"coin_satisfaction", phase.CoinSatisfaction)
+	}
+	if churn := report.Churn; churn != nil {
+		add("churn_joined", churn.Joined)
+		add("churn_retired", churn.Retired)
+		add("churn_withdrawn_coins", churn.WithdrawnCoins)
+		add("churn_cut_rings", churn.CutRings)
+	}
+	if network := report.Network; network != nil {
+		add("network_sent", network.Sent)
+		add("network_delivered", network.Delivered)
+		add("network_delayed", network.Delayed)
+		add("network_lost", network.Lost)
+		add("network_partitioned", network.Partitioned)
+		add("network_duplicated", network.Duplicated)
+		add("network_reordered", network.Reordered)
+		add("network_timed_out", network.TimedOut)
+		add("network_undelivered", network.Undelivered)
+		add("network_errors", network.Errors)
+		add("network_conflicts", network.Conflicts)
+	}
+	if votes := report.Votes; votes != nil {
+		add("vote_certificates", votes.Certificates)
+		add("vote_invalid_certificates", votes.InvalidCertificates)
+		add("vote_votes", votes.Votes)
+		add("vote_equivocations", votes.Equivocations)
+	}
+	if slashing := report.Slashing; slashing != nil {
+		add("slash_minority_votes", slashing.MinorityVotes)
+		for _, behavior := range slashing.Behaviors {
+			prefix := "slash_" + behavior.Behavior + "_"
+			add(prefix+"equivocations", behavior.Equivocations)
+			add(prefix+"invalid_proposals", behavior.InvalidProposals)
+			add(prefix+"invalid_approvals", behavior.InvalidApprovals)
+			add(prefix+"unrevealed", behavior.Unrevealed)
+			add(prefix+"slashed", behavior.Slashed)
+		}
+	}
+	if reputation := report.Reputation; reputation != nil {
+		add("reputation_bad_traders", reputation.BadTraders)
+		add("reputation_normal_score", reputation.NormalScore)
+		add("reputation_bad_score", reputation.BadScore)
+		for index, phase := range reputation.Phases {
+			prefix := fmt.Sprintf("reputation_%d_", index)
+			add(prefix+"time", phase.Time)
+			add(prefix+"fractals", phase.Fractals)
+			add(prefix+"bad_seats", phase.BadSeats)
+		}
+	}
+	if voting := report.Voting; voting != nil {
+		add("voting_rule", voting.Rule)
+		add("voting_quorum", voting.Quorum)
+		add("voting_verifications", voting.Verifications)
+		add("voting_verification_flips", voting.VerificationFlips)
+		add("voting_invalid_accepted", voting.InvalidAccepted)
+		add("voting_count_invalid_accepted", voting.CountInvalidAccepted)
+		add("voting_valid_rejected", voting.ValidRejected)
+		add("voting_count_valid_rejected", voting.CountValidRejected)
+		add("voting_rounds", voting.Rounds)
+		add("voting_round_flips", voting.RoundFlips)
+	}
+	if copycat := report.Copycat; copycat != nil {
+		add("copycat_members", copycat.Members)
+		add("copycat_commit_reveal", copycat.CommitReveal)
+		add("copycat_agreement", copycat.Agreement)
+		add("copycat_normal_agreement", copycat.NormalAgreement)
+		add("copycat_bans", copycat.Bans)
+		add("copycat_normal_bans", copycat.NormalBans)
+		add("copycat_unrevealed", copycat.Unrevealed)
+		add("copycat_slashed", copycat.Slashed)
+	}
+	if ledger := report.Ledger; ledger != nil {
+		add("ledger_entries", ledger.Entries)
+		add("ledger_mismatched", ledger.Mismatched)
+		add("ledger_diverging", ledger.Diverging)
+	}
+	if supply := report.Supply; supply != nil {
+		add("supply_total", supply.Total())
+		add("supply_payouts", supply.Payouts)
+		add("supply_settled", supply.Settled)
+		add("invariant_checks", supply.Checks)
+	}
+	if matching := report.Matching; matching != nil {
+		add("matching_matched", matching.Matched)
+		add("matching_refunded", matching.Refunded)
+		add("matching_refunded_amount", matching.RefundedAmount)
+		add("matching_running", matching.Running)
+		add("matching_latency_mean", matching.Latency.Mean)
+		add("matching_latency_p50", matching.Latency.P50)
+		add("matching_latency_p90", matching.Latency.P90)
+		add("matching_latency_p99", matching.Latency.P99)
+		add("matching_latency_max", matching.Latency.Max)
+		for _, coinType := range matching.CoinTypes {
+			prefix := fmt.Sprintf("matching_type_%d_", coinType.Type)
+			add(prefix+"count", coinType.Count)
+			add(prefix+"latency_mean", coinType.Mean)
+			add(prefix+"latency_p50", coinType.P50)
+			add(prefix+"latency_p90", coinType.P90)
+			add(prefix+"latency_p99", coinType.P99)
+			add(prefix+"latency_max", coinType.Max)
+		}
+	}
+	return
+}
+
+func describeLatency(latency LatencyReport) string {
+	return fmt.Sprintf("%d coins, mean %.2f, p50 %d, p90 %d, p99 %d, max %d", latency.Count, latency.Mean, latency.P50, latency.P90, latency.P99, latency.Max)
+}
+
+func describeEntry(entry *pkg.LedgerEntry) string {
+	if entry == nil {
+		return "missing"
+	}
+	return fmt.Sprintf("%s %.2f", entry.Kind, entry.Amount)
+}
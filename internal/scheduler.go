@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"container/heap"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type EventKind int
+
+const (
+	CoinEvent EventKind = iota
+	CheckEvent
+	RoundEvent
+	SybilEvent
+	ChurnEvent
+	NetworkEvent
+	ExpiryEvent
+)
+
+type Event struct {
+	Time      int64        `json:"time"`
+	Seq       int          `json:"seq"`
+	Kind      EventKind    `json:"kind"`
+	TraderID  string       `json:"trader_id,omitempty"`
+	FractalID string       `json:"fractal_id,omitempty"`
+	CoinID    string       `json:"coin_id,omitempty"`
+	Round     int          `json:"round,omitempty"`
+	Message   *pkg.Message `json:"message,omitempty"`
+}
+
+type eventQueue []Event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].Time != q[j].Time {
+		return q[i].Time < q[j].Time
+	}
+	return q[i].Seq < q[j].Seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x any) { *q = append(*q, x.(Event)) }
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	event := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return event
+}
+
+type Scheduler struct {
+	Clock    int64      `json:"clock"`
+	Counter  int        `json:"counter"`
+	Events   eventQueue `json:"events"`
+	Deferred []Event    `json:"deferred"`
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		Clock:    0,
+		Counter:  0,
+		Events:   make(eventQueue, 0),
+		Deferred: make([]Event, 0),
+	}
+}
+
+func (s *Scheduler) Schedule(delay int64, event Event) {
+	event.Time, event.Seq = s.Clock+delay, s.Counter
+	s.Counter++
+	heap.Push(&s.Events, event)
+}
+
+func (s *Scheduler) Peek() (Event, bool) {
+	if len(s.Events) == 0 {
+		return Event{}, false
+	}
+	return s.Events[0], true
+}
+
+func (s *Scheduler) Next() (Event, bool) {
+	if len(s.Events) == 0 {
+		return Event{}, false
+	}
+	event := heap.Pop(&s.Events).(Event)
+	s.Clock = event.Time
+	return event, true
+}
+
+func (s *Scheduler) Defer(event Event) {
+	s.Deferred = append(s.Deferred, event)
+}
+
+func (s *Scheduler) Resume() {
+	for _, event := range s.Deferred {
+		event.Time = max(event.Time, s.Clock)
+		heap.Push(&s.Events, event)
+	}
+	s.Deferred = s.Deferred[:0]
+}
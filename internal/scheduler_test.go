@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func drainScheduler(s *Scheduler) ([]string, []int64) {
+	var order []string
+	var times []int64
+	for {
+		event, ok := s.Next()
+		if !ok {
+			return order, times
+		}
+		order = append(order, event.TraderID)
+		times = append(times, s.Clock)
+	}
+}
+
+func TestSchedulerOrdersEventsByTimeThenSequence(t *testing.T) {
+	s := NewScheduler()
+	for _, scheduled := range []struct {
+		delay int64
+		name  string
+	}{{30, "d"}, {10, "a"}, {20, "c"}, {10, "b"}, {30, "e"}, {0, "first"}} {
+		s.Schedule(scheduled.delay, Event{Kind: CheckEvent, TraderID: scheduled.name})
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewScheduler()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scheduler := range []*Scheduler{s, restored} {
+		order, times := drainScheduler(scheduler)
+		expected, expectedTimes := []string{"first", "a", "b", "c", "d", "e"}, []int64{0, 10, 10, 20, 30, 30}
+		for i := range expected {
+			if order[i] != expected[i] || times[i] != expectedTimes[i] {
+				t.Fatalf("expected %v at %v, got %v at %v", expected, expectedTimes, order, times)
+			}
+		}
+	}
+}
+
+func TestSchedulerSchedulesRelativeToTheClock(t *testing.T) {
+	s := NewScheduler()
+	s.Schedule(100, Event{TraderID: "late"})
+	s.Schedule(50, Event{TraderID: "early"})
+	if event, _ := s.Next(); event.TraderID != "early" || s.Clock != 50 {
+		t.Fatalf("expected the early event at 50, got %s at %d", event.TraderID, s.Clock)
+	}
+
+	s.Schedule(50, Event{TraderID: "tie"})
+	s.Schedule(10, Event{TraderID: "next"})
+	if event, ok := s.Peek(); !ok || event.TraderID != "next" || event.Time != 60 {
+		t.Fatalf("expected the next event at 60, got %s at %d", event.TraderID, event.Time)
+	}
+	order, times := drainScheduler(s)
+	if len(order) != 3 || order[0] != "next" || order[1] != "late" || order[2] != "tie" || times[1] != 100 || times[2] != 100 {
+		t.Fatalf("expected next, late and then tie at 60, 100 and 100, got %v at %v", order, times)
+	}
+}
+
+func TestSchedulerResumesDeferredEventsAtTheClock(t *testing.T) {
+	s := NewScheduler()
+	s.Schedule(10, Event{TraderID: "deferred"})
+	s.Schedule(20, Event{TraderID: "other"})
+	deferred, _ := s.Next()
+	s.Defer(deferred)
+	if event, _ := s.Next(); event.TraderID != "other" {
+		t.Fatalf("expected the other event while one is deferred, got %s", event.TraderID)
+	}
+
+	s.Schedule(0, Event{TraderID: "now"})
+	s.Resume()
+	if len(s.Deferred) != 0 {
+		t.Fatalf("expected no deferred events after resuming, got %d", len(s.Deferred))
+	}
+	order, times := drainScheduler(s)
+	if len(order) != 2 || order[0] != "deferred" || order[1] != "now" || times[0] != 20 || times[1] != 20 {
+		t.Fatalf("expected the deferred event before the newer one at the current clock, got %v at %v", order, times)
+	}
+}
@@ -0,0 +1,301 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+const SnapshotVersion = 4
+
+type snapshot struct {
+	Version int `json:"version"`
+	*System
+	RandomState        []byte                          `json:"random_state"`
+	CoinIDs            []string                        `json:"coin_ids,omitempty"`
+	SharedCoins        map[string]pkg.CoinTable        `json:"shared_coins,omitempty"`
+	SharedCooperations map[string]pkg.CooperationTable `json:"shared_cooperations,omitempty"`
+	SharedUnusedCoins  map[string][][]string           `json:"shared_unused_coins,omitempty"`
+	TraderStates       map[string]*viewState           `json:"trader_states"`
+}
+
+type viewState struct {
+	*pkg.TraderState
+	MissingCoins        []string `json:"missing_coins,omitempty"`
+	MissingCooperations []string `json:"missing_cooperations,omitempty"`
+	MissingUnusedCoins  []string `json:"missing_unused_coins,omitempty"`
+}
+
+func (system *System) Save(filePath string) error {
+	randomState, err := system.Random.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if system.Network != nil {
+		if system.Network.RandomState, err = system.Network.random.MarshalBinary(); err != nil {
+			return err
+		}
+	}
+
+	traderStates := make(map[string]*pkg.TraderState)
+	for traderID, trader := range system.Traders {
+		if trader.Data == nil {
+			continue
+		}
+		state, err := trader.State()
+		if err != nil {
+			return err
+		}
+		traderStates[traderID] = state
+	}
+	coinIDs := internCoinIDs(traderStates)
+
+	views := make(map[string]*viewState, len(traderStates))
+	coins := make(map[string]map[string]pkg.CoinTable, len(traderStates))
+	cooperations := make(map[string]map[string]pkg.CooperationTable, len(traderStates))
+	unusedCoins := make(map[string]map[string][][]string, len(traderStates))
+	for traderID, state := range traderStates {
+		views[traderID] = &viewState{TraderState: state}
+		coins[traderID], cooperations[traderID], unusedCoins[traderID] = state.Coins, state.Cooperations, state.UnusedCoins
+	}
+	sharedCoins, missingCoins := shareEntries(coins)
+	sharedCooperations, missingCooperations := shareEntries(cooperations)
+	sharedUnusedCoins, missingUnusedCoins := shareEntries(unusedCoins)
+	for traderID, view := range views {
+		view.MissingCoins, view.MissingCooperations, view.MissingUnusedCoins = missingCoins[traderID], missingCooperations[traderID], missingUnusedCoins[traderID]
+	}
+
+	data, err := json.Marshal(snapshot{
+		Version:            SnapshotVersion,
+		System:             system,
+		RandomState:        randomState,
+		CoinIDs:            coinIDs,
+		SharedCoins:        sharedCoins,
+		SharedCooperations: sharedCooperations,
+		SharedUnusedCoins:  sharedUnusedCoins,
+		TraderStates:       views,
+	})
+	if err != nil {
+		return err
+	}
+	return WriteFile(filePath, func(out io.Writer) error {
+		_, err := out.Write(data)
+		return err
+	})
+}
+
+func WriteFile(filePath string, write func(io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	} else if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
+
+func Load(filePath string) (*System, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	system := NewSystem(0, pkg.DefaultParams())
+	loaded := snapshot{System: system}
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, err
+	} else if loaded.Version > SnapshotVersion {
+		return nil, errors.New("unsupported snapshot version")
+	}
+
+	if system.Coalition == nil {
+		system.Coalition = pkg.NewCoalition()
+	}
+	if loaded.RandomState != nil {
+		if err := system.Random.UnmarshalBinary(loaded.RandomState); err != nil {
+			return nil, err
+		}
+	} else if system.Seed != 0 {
+		system.Random = tools.NewRandom(system.Seed)
+	}
+
+	for _, traderID := range slices.Sorted(maps.Keys(system.Traders)) {
+		if system.isActive(traderID) {
+			system.traderIDs = append(system.traderIDs, traderID)
+		}
+		if view, ok := loaded.TraderStates[traderID]; ok && view.TraderState != nil {
+			trader, state := system.Traders[traderID], view.TraderState
+			state.Coins = unshareEntries(state.Coins, loaded.SharedCoins, view.MissingCoins)
+			state.Cooperations = unshareEntries(state.Cooperations, loaded.SharedCooperations, view.MissingCooperations)
+			state.UnusedCoins = unshareEntries(state.UnusedCoins, loaded.SharedUnusedCoins, view.MissingUnusedCoins)
+			if loaded.CoinIDs != nil {
+				resolved, err := resolveCoinIDs(*state, loaded.CoinIDs)
+				if err != nil {
+					return nil, err
+				}
+				state = &resolved
+			}
+			if err := trader.Restore(&system.Params, state); err != nil {
+				return nil, err
+			} else if err := system.setStrategy(trader); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if system.Network != nil {
+		system.Network.random = tools.NewRandom(0)
+		if err := system.Network.random.UnmarshalBinary(system.Network.RandomState); err != nil {
+			return nil, err
+		}
+	}
+	system.UseTransport(pkg.NewLocalTransport())
+	return system, nil
+}
+
+func (system *System) SetParams(params pkg.Params) error {
+	system.Params = params
+	for _, trader := range system.Traders {
+		if trader.Data == nil {
+			continue
+		}
+		if err := system.setStrategy(trader); err != nil {
+			return err
+		}
+		trader.SetLedger(params.Ledger)
+	}
+	if !params.CheckInvariants {
+		system.Supply = nil
+	} else if system.Supply == nil {
+		system.openSupply()
+	}
+	return nil
+}
+
+func (system *System) Resumable() bool {
+	for _, trader := range system.Traders {
+		if trader.Data == nil {
+			return false
+		}
+	}
+	return system.Scheduler != nil
+}
+
+func internCoinIDs(states map[string]*pkg.TraderState) []string {
+	seen := make(map[string]bool)
+	for _, state := range states {
+		for _, coinID := range state.CoinIDs() {
+			seen[coinID] = true
+		}
+	}
+	delete(seen, "")
+
+	coinIDs := slices.Sorted(maps.Keys(seen))
+	indexes := make(map[string]string, len(coinIDs))
+	for index, coinID := range coinIDs {
+		indexes[coinID] = "#" + strconv.Itoa(index)
+	}
+	for traderID, state := range states {
+		interned := state.MapCoinIDs(func(coinID string) string {
+			if coinID == "" {
+				return ""
+			}
+			return indexes[coinID]
+		})
+		states[traderID] = &interned
+	}
+	return coinIDs
+}
+
+func shareEntries[V any](views map[string]map[string]V) (map[string]V, map[string][]string) {
+	encodings := make(map[string]map[string]string, len(views))
+	counts := make(map[string]map[string]int)
+	values := make(map[string]V)
+	for traderID, entries := range views {
+		encodings[traderID] = make(map[string]string, len(entries))
+		for key, value := range entries {
+			data, _ := json.Marshal(value)
+			encodings[traderID][key] = string(data)
+			if counts[key] == nil {
+				counts[key] = make(map[string]int)
+			}
+			counts[key][string(data)]++
+		}
+	}
+
+	shared, common := make(map[string]V), make(map[string]string)
+	for key, seen := range counts {
+		best := ""
+		for encoding, count := range seen {
+			if count > seen[best] || (count == seen[best] && encoding < best) {
+				best = encoding
+			}
+		}
+		if seen[best] > 1 {
+			common[key] = best
+		}
+	}
+	for traderID, entries := range views {
+		for key, value := range entries {
+			if encoding, ok := common[key]; ok && encodings[traderID][key] == encoding {
+				values[key] = value
+				delete(entries, key)
+			}
+		}
+	}
+	for key := range common {
+		shared[key] = values[key]
+	}
+
+	missing, keys := make(map[string][]string), slices.Sorted(maps.Keys(common))
+	for traderID := range views {
+		for _, key := range keys {
+			if _, ok := encodings[traderID][key]; !ok {
+				missing[traderID] = append(missing[traderID], key)
+			}
+		}
+	}
+	return shared, missing
+}
+
+func unshareEntries[V any](entries map[string]V, shared map[string]V, missing []string) map[string]V {
+	if len(shared) == 0 {
+		return entries
+	}
+	result := maps.Clone(shared)
+	for _, key := range missing {
+		delete(result, key)
+	}
+	maps.Copy(result, entries)
+	return result
+}
+
+func resolveCoinIDs(state pkg.TraderState, coinIDs []string) (pkg.TraderState, error) {
+	var err error
+	resolved := state.MapCoinIDs(func(reference string) string {
+		if reference == "" {
+			return ""
+		}
+		index, e := strconv.Atoi(strings.TrimPrefix(reference, "#"))
+		if e != nil || !strings.HasPrefix(reference, "#") || index < 0 || index >= len(coinIDs) {
+			err = errors.New("invalid coin reference in snapshot")
+			return ""
+		}
+		return coinIDs[index]
+	})
+	return resolved, err
+}
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type Sweep struct {
+	Params   pkg.Params
+	Randoms  []float64
+	Bads     []float64
+	Alphas   []float64
+	Traders  int
+	Types    uint
+	Repeats  int
+	Workers  int
+	MaxTicks int64
+	MaxCoins int
+	Seed     uint64
+	Dir      string
+}
+
+type SweepPoint struct {
+	Name   string
+	Random float64
+	Bad    float64
+	Alpha  float64
+	Repeat int
+	Seed   uint64
+}
+
+func ParseRange(spec string) ([]float64, error) {
+	if parts := strings.Split(spec, ":"); len(parts) == 3 {
+		start, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		step, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return nil, err
+		} else if step <= 0 {
+			return nil, errors.New("range step must be positive")
+		}
+
+		values := make([]float64, 0)
+		for i := 0; start+float64(i)*step <= end+1e-9; i++ {
+			values = append(values, start+float64(i)*step)
+		}
+		return values, nil
+	}
+
+	values := make([]float64, 0)
+	for _, part := range strings.Split(spec, ",") {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (sweep Sweep) Points() []SweepPoint {
+	points := make([]SweepPoint, 0)
+	for _, random := range sweep.Randoms {
+		for _, bad := range sweep.Bads {
+			if random+bad > 100 {
+				continue
+			}
+			for _, alpha := range sweep.Alphas {
+				for repeat := 0; repeat < sweep.Repeats; repeat++ {
+					name, seed := fmt.Sprintf("%g-%g-%g-%d", random, bad, alpha, repeat), uint64(0)
+					if sweep.Seed != 0 {
+						seed = binary.BigEndian.Uint64(tools.SHA256(fmt.Sprintf("sweep-%d-%s", sweep.Seed, name)))
+					}
+					points = append(points, SweepPoint{
+						Name:   name,
+						Random: random,
+						Bad:    bad,
+						Alpha:  alpha,
+						Repeat: repeat,
+						Seed:   seed,
+					})
+				}
+			}
+		}
+	}
+	return points
+}
+
+func (sweep Sweep) Run(out io.Writer) error {
+	if err := os.MkdirAll(sweep.Dir, 0755); err != nil {
+		return err
+	}
+
+	points := sweep.Points()
+	reports, errs := make([]Report, len(points)), make([]error, len(points))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(sweep.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				reports[index], errs[index] = sweep.runPoint(points[index])
+			}
+		}()
+	}
+	for index := range points {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	header := []string{"name", "random", "bad", "alpha", "repeat", "seed"}
+	columns := make(map[string]int)
+	rows := make([]map[string]string, len(points))
+	for index, point := range points {
+		if errs[index] != nil {
+			return fmt.Errorf("point %s: %w", point.Name, errs[index])
+		}
+
+		keys, values := reports[index].Fields()
+		rows[index] = make(map[string]string, len(keys))
+		for i, key := range keys {
+			if _, ok := columns[key]; !ok {
+				columns[key] = len(header)
+				header = append(header, key)
+			}
+			rows[index][key] = values[i]
+		}
+	}
+
+	writer := csv.NewWriter(out)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for index, point := range points {
+		values := make([]string, len(header))
+		copy(values, []string{point.Name, fmt.Sprint(point.Random), fmt.Sprint(point.Bad), fmt.Sprint(point.Alpha), fmt.Sprint(point.Repeat), fmt.Sprint(point.Seed)})
+		for key, value := range rows[index] {
+			values[columns[key]] = value
+		}
+		if err := writer.Write(values); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (sweep Sweep) runPoint(point SweepPoint) (Report, error) {
+	jsonFile := filepath.Join(sweep.Dir, point.Name+".json")
+	resultFile := filepath.Join(sweep.Dir, point.Name+".result")
+
+	params := sweep.Params
+	params.BadBehavior = point.Alpha / 100
+	numRandoms := int(float64(sweep.Traders) * point.Random / 100)
+	numBads := int(float64(sweep.Traders) * point.Bad / 100)
+
+	var system *System
+	if _, err := os.Stat(jsonFile); err == nil {
+		log.Printf("Loading from %s...\n", jsonFile)
+		if system, err = Load(jsonFile); err != nil {
+			return Report{}, err
+		} else if !system.matches(params, point.Seed, sweep.Traders, numRandoms, numBads) {
+			log.Printf("Snapshot %s does not match point %s, running it again...\n", jsonFile, point.Name)
+			system = nil
+		}
+	}
+	if system == nil {
+		system = NewSystem(point.Seed, params)
+		log.Printf("Running with %g%% random traders, %g%% bad traders and alpha=%g%% (repeat %d, seed %d)...\n", point.Random, point.Bad, point.Alpha, point.Repeat, system.Seed)
+
+		if err := system.Init(sweep.Traders, numRandoms, numBads, 0, 0, sweep.Types); err != nil {
+			return Report{}, err
+		}
+		system.Start(sweep.MaxTicks, sweep.MaxCoins)
+		if err := system.Save(jsonFile); err != nil {
+			return Report{}, err
+		}
+	}
+
+	report := AnalyzeSystem(system)
+	if err := WriteFile(resultFile, report.WriteText); err != nil {
+		return Report{}, err
+	}
+
+	log.Printf("Point %s finished.\n", point.Name)
+	return report, nil
+}
+
+func (system *System) matches(params pkg.Params, seed uint64, numTraders, numRandoms, numBads int) bool {
+	if system.Params != params || (seed != 0 && system.Seed != seed) || len(system.Traders) != numTraders {
+		return false
+	}
+	counts := make(map[pkg.BehaviorType]int)
+	for _, trader := range system.Traders {
+		if trader.Data != nil {
+			counts[trader.Data.TraderType]++
+		}
+	}
+	return counts[pkg.RandomVote] == numRandoms && counts[pkg.BadVote] == numBads
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func newTestSweep(t *testing.T) Sweep {
+	t.Helper()
+	params := pkg.DefaultParams()
+	params.SignatureScheme = tools.Ed25519
+	return Sweep{
+		Params:   params,
+		Randoms:  []float64{0},
+		Bads:     []float64{0, 20},
+		Alphas:   []float64{10},
+		Traders:  10,
+		Types:    2,
+		Repeats:  1,
+		Workers:  2,
+		MaxTicks: 2000,
+		Seed:     7,
+		Dir:      t.TempDir(),
+	}
+}
+
+func TestSweepPointSeedsDependOnlyOnThePoint(t *testing.T) {
+	sweep := newTestSweep(t)
+	seeds := make(map[string]uint64)
+	for _, point := range sweep.Points() {
+		seeds[point.Name] = point.Seed
+	}
+
+	sweep.Bads = []float64{20}
+	for _, point := range sweep.Points() {
+		if point.Seed != seeds[point.Name] {
+			t.Fatalf("expected point %s to keep seed %d, got %d", point.Name, seeds[point.Name], point.Seed)
+		}
+	}
+	if seeds["0-0-10-0"] == seeds["0-20-10-0"] {
+		t.Fatal("expected different points to get different seeds")
+	}
+}
+
+func TestSweepResumeSkipsFinishedPoints(t *testing.T) {
+	sweep := newTestSweep(t)
+	var first bytes.Buffer
+	if err := sweep.Run(&first); err != nil {
+		t.Fatal(err)
+	}
+
+	finished := time.Unix(1, 0)
+	for _, point := range sweep.Points() {
+		if err := os.Chtimes(filepath.Join(sweep.Dir, point.Name+".json"), finished, finished); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modified := func(point SweepPoint) bool {
+		info, err := os.Stat(filepath.Join(sweep.Dir, point.Name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !info.ModTime().Equal(finished)
+	}
+
+	var second bytes.Buffer
+	if err := sweep.Run(&second); err != nil {
+		t.Fatal(err)
+	} else if first.String() != second.String() {
+		t.Fatalf("expected the resumed sweep to report the same results, got\n%s\ninstead of\n%s", second.String(), first.String())
+	}
+	for _, point := range sweep.Points() {
+		if modified(point) {
+			t.Fatalf("expected finished point %s to be loaded instead of run again", point.Name)
+		}
+	}
+
+	sweep.Seed = 8
+	if err := sweep.Run(&bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, point := range sweep.Points() {
+		if !modified(point) {
+			t.Fatalf("expected point %s with another seed to be run again", point.Name)
+		} else if system, err := Load(filepath.Join(sweep.Dir, point.Name+".json")); err != nil {
+			t.Fatal(err)
+		} else if system.Seed != point.Seed {
+			t.Fatalf("expected point %s to be saved with seed %d, got %d", point.Name, point.Seed, system.Seed)
+		}
+	}
+}
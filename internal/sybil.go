@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"log"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type SybilAttack struct {
+	Count    int     `json:"count"`
+	Waves    int     `json:"waves"`
+	Start    int64   `json:"start"`
+	Interval int64   `json:"interval"`
+	Account  float64 `json:"account"`
+
+	Phases []SybilPhase `json:"phases"`
+}
+
+type SybilPhase struct {
+	Time    int64 `json:"time"`
+	Sybils  int   `json:"sybils"`
+	Traders int   `json:"traders"`
+}
+
+func (system *System) ScheduleSybilAttack(attack SybilAttack) {
+	attack.Phases = []SybilPhase{{Time: 0, Sybils: 0, Traders: len(system.Traders)}}
+	system.SybilAttack = &attack
+	for wave := 0; wave < attack.Waves; wave++ {
+		delay := attack.Start + int64(wave)*attack.Interval - system.Scheduler.Clock
+		system.Scheduler.Schedule(max(delay, 0), Event{Kind: SybilEvent, Round: wave})
+	}
+}
+
+func (system *System) mintSybils(wave int) error {
+	attack := system.SybilAttack
+	count := attack.Count / attack.Waves
+	if wave < attack.Count%attack.Waves {
+		count++
+	}
+
+	for i := 0; i < count; i++ {
+		trader, err := system.CreateTrader(pkg.Sybil, attack.Account)
+		if err != nil {
+			return err
+		}
+		if err := system.AddTrader(trader); err != nil {
+			return err
+		}
+	}
+
+	last := attack.Phases[len(attack.Phases)-1]
+	attack.Phases = append(attack.Phases, SybilPhase{
+		Time:    system.Scheduler.Clock,
+		Sybils:  last.Sybils + count,
+		Traders: len(system.Traders),
+	})
+	log.Printf("Sybil wave %d: %d sybil traders joined (%d in total)\n", wave+1, count, last.Sybils+count)
+	return nil
+}
@@ -1,25 +1,44 @@
 package internal
 
 import (
-	"encoding/json"
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"log"
-	"math/rand"
-	"os"
+	"maps"
+	"slices"
 	"sync"
 	"syscall"
-	"time"
 
 	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
 	"github.com/google/uuid"
 )
 
-const (
-	Debug       = false
-	RunFractals = true
-)
+type Proposal struct {
+	FractalID      string `json:"fractal_id"`
+	Proposer       string `json:"proposer"`
+	Time           int64  `json:"time"`
+	IsValid        bool   `json:"is_valid"`
+	Accepted       bool   `json:"accepted"`
+	TeamSize       int    `json:"team_size"`
+	CoalitionSeats int    `json:"coalition_seats"`
+	BadSeats       int    `json:"bad_seats"`
+	Verified       bool   `json:"verified"`
+	CountVerified  bool   `json:"count_verified"`
+}
+
+type Slash struct {
+	pkg.Fault
+	Behavior pkg.BehaviorType `json:"behavior"`
+	Amount   float64          `json:"amount"`
+	Time     int64            `json:"time"`
+}
 
 type System struct {
+	Seed           uint64
+	Params         pkg.Params
+	CoinTypeCount  uint
 	BadAcceptCount int
 	BadRejectCount int
 	FractalCounter int
@@ -29,10 +48,52 @@ type System struct {
 	Traders        map[string]*pkg.Trader
 	Coins          map[string]pkg.CoinTable
 	Fractals       map[string]*pkg.FractalRing
+	Coalition      *pkg.Coalition
+	Proposals      []Proposal
+	SybilAttack    *SybilAttack
+	Churn          *Churn
+	Joined         map[string]int64
+	Retired        map[string]int64
+	CutRings       int
+	Equivocations  []pkg.Equivocation
+	Slashes        []Slash
+	MinorityVotes  int
+	Reputations    pkg.Reputations
+	RoundDecisions int
+	RoundFlips     int
+	Horizon        int64
+	CoinLimit      int
+	CoinCount      int
+	Checkpoint     *Checkpoint
+	Network        *NetworkFaults
+	Scheduler      *Scheduler
+	Supply         *Supply
+
+	Random    *tools.Random `json:"-"`
+	Transport pkg.Transport `json:"-"`
+
+	traderIDs   []string
+	pending     pkg.Reputations
+	verdict     Proposal
+	inbox       []pkg.Message
+	inboxLocker sync.Mutex
+	checked     int
+	unchecked   bool
 }
 
-func NewSystem() *System {
-	return &System{
+func NewSystem(seed uint64, params pkg.Params) *System {
+	for seed == 0 {
+		var data [8]byte
+		if _, err := crand.Read(data[:]); err != nil {
+			break
+		}
+		seed = binary.LittleEndian.Uint64(data[:])
+	}
+
+	system := &System{
+		Seed:           seed,
+		Params:         params,
+		Random:         tools.NewRandom(seed),
 		BadAcceptCount: 0,
 		BadRejectCount: 0,
 		FractalCounter: 0,
@@ -42,34 +103,48 @@ func NewSystem() *System {
 		Traders:        make(map[string]*pkg.Trader),
 		Coins:          make(map[string]pkg.CoinTable),
 		Fractals:       make(map[string]*pkg.FractalRing),
-	}
+		Coalition:      pkg.NewCoalition(),
+		Proposals:      make([]Proposal, 0),
+		Joined:         make(map[string]int64),
+		Retired:        make(map[string]int64),
+		Reputations:    make(pkg.Reputations),
+		Scheduler:      NewScheduler(),
+		pending:        make(pkg.Reputations),
+	}
+	if params.CheckInvariants {
+		system.Supply = &Supply{}
+	}
+	system.UseTransport(pkg.NewLocalTransport())
+	return system
 }
 
-func (system *System) ProcessCoin(coin pkg.CoinTable) error {
+func (system *System) ProcessCoin(coin pkg.CoinTable, err error) error {
 	system.Locker.Lock()
 	defer system.Locker.Unlock()
 
 	system.Coins[coin.ID] = coin
-	if err := system.saveCoinToTraders(coin); err != nil {
+	if system.Supply != nil {
+		system.Supply.Minted += coin.Amount
+	}
+	if coin.Expires != 0 {
+		system.Scheduler.Schedule(max(coin.Expires-system.Scheduler.Clock, 0), Event{Kind: ExpiryEvent, CoinID: coin.ID})
+	}
+	if err != nil {
 		return err
 	}
 
-	return system.processTradersForCoin(coin)
-}
-
-func (system *System) saveCoinToTraders(coin pkg.CoinTable) error {
-	for _, t := range system.Traders {
-		if err := t.SaveCoin(coin); err != nil {
-			return err
-		}
-	}
+	system.Scheduler.Schedule(0, Event{Kind: CheckEvent, TraderID: coin.Owner})
 	return nil
 }
 
-func (system *System) processTradersForCoin(coin pkg.CoinTable) error {
-	for index, traderID := range system.getShuffledTraderIDs(coin.Owner) {
+func (system *System) processTradersForCoin(owner string) error {
+	for index, traderID := range system.getShuffledTraderIDs(owner) {
 		trader := system.Traders[traderID]
-		if fractal := trader.CheckForRings(system.FractalCounter); fractal != nil {
+		replies, err := system.exchange(pkg.Message{Kind: pkg.CheckMessage, To: traderID, Round: system.FractalCounter})
+		if err != nil {
+			return err
+		}
+		if fractal := repliesFrom(replies)[traderID].Fractal; fractal != nil {
 			system.FractalCounter++
 			system.SubmitCount[traderID]++
 			if err := system.handleFractal(trader, fractal, index); err != nil {
@@ -82,7 +157,25 @@ func (system *System) processTradersForCoin(coin pkg.CoinTable) error {
 }
 
 func (system *System) handleFractal(trader *pkg.Trader, fractal *pkg.FractalRing, index int) error {
-	if err := system.processFractal(trader, fractal); err != nil {
+	system.verdict = Proposal{}
+	err := system.processFractal(trader, fractal)
+	system.Proposals = append(system.Proposals, Proposal{
+		FractalID:      fractal.ID,
+		Proposer:       trader.ID,
+		Time:           system.Scheduler.Clock,
+		IsValid:        fractal.IsValid,
+		Accepted:       err == nil,
+		TeamSize:       len(fractal.VerificationTeam),
+		CoalitionSeats: system.Coalition.Seats(fractal.VerificationTeam),
+		BadSeats:       system.badSeats(fractal.VerificationTeam),
+		Verified:       system.verdict.Verified,
+		CountVerified:  system.verdict.CountVerified,
+	})
+	system.pending.AddProposal(trader.ID, err == nil)
+	if e := system.updateReputations(); e != nil {
+		return e
+	}
+	if err != nil {
 		if fractal.IsValid {
 			system.BadRejectCount++
 		}
@@ -92,24 +185,22 @@ func (system *System) handleFractal(trader *pkg.Trader, fractal *pkg.FractalRing
 	if !fractal.IsValid {
 		system.BadAcceptCount++
 	}
-	if Debug {
+	if system.Params.Debug {
 		log.Printf("Fractal ring created by trader %d with %d cooperation rings and %d verification team members\n", index+1, len(fractal.CooperationRings), len(fractal.VerificationTeam))
 	}
-	if RunFractals {
-		if err := system.runFractal(fractal); err != nil {
-			return err
-		}
+	if system.Params.RunFractals {
+		system.Scheduler.Schedule(system.Params.RoundLength, Event{Kind: RoundEvent, FractalID: fractal.ID, Round: 0})
 	}
 	return nil
 }
 
 func (system *System) getShuffledTraderIDs(firstID string) (result []string) {
-	for traderID := range system.Traders {
+	for _, traderID := range system.traderIDs {
 		if traderID != firstID {
 			result = append(result, traderID)
 		}
 	}
-	rand.Shuffle(len(result), func(i, j int) {
+	system.Random.Shuffle(len(result), func(i, j int) {
 		result[i], result[j] = result[j], result[i]
 	})
 
@@ -121,7 +212,9 @@ func (system *System) getShuffledTraderIDs(firstID string) (result []string) {
 
 func (system *System) processFractal(trader *pkg.Trader, fractal *pkg.FractalRing) error {
 	if err := system.verifyFractal(fractal); err != nil {
-		trader.RemoveFractalRing(fractal.ID)
+		if _, e := system.exchange(pkg.Message{Kind: pkg.RejectMessage, To: trader.ID, Fractal: fractal}); e != nil {
+			return e
+		}
 		return err
 	} else if err := system.checkCoins(fractal); err != nil {
 		return err
@@ -134,30 +227,43 @@ func (system *System) processFractal(trader *pkg.Trader, fractal *pkg.FractalRin
 }
 
 func (system *System) verifyFractal(fractal *pkg.FractalRing) error {
-	accepted, rejected := []string{}, []string{}
-	for _, traderID := range fractal.VerificationTeam {
-		if err := system.Traders[traderID].SubmitRing(fractal); err != nil {
-			rejected = append(rejected, traderID)
-		} else {
-			accepted = append(accepted, traderID)
+	votes, err := system.castVotes(pkg.Message{Kind: pkg.VerifyMessage, Fractal: fractal}, fractal.VerificationTeam)
+	if err != nil {
+		return err
+	}
+
+	certificate := pkg.NewCertificate(fractal.ID, "", pkg.VerificationRound, fractal.VerificationTeam, votesOn(votes, fractal.ID, "", pkg.VerificationRound), system.Params.Quorum, pkg.VoteWeights(fractal.VerificationTeam, system.voteWeigher()))
+	system.verdict.Verified, system.verdict.CountVerified = certificate.Accepted, certificate.CountAccepted(fractal.VerificationTeam)
+	system.pending.AddVotes(certificate)
+	if pkg.CheckFractalRing(&system.Params, fractal, system.traderIDs, system.teamWeights(), system.publicKey(fractal.Proposer), system.AcceptedCount[fractal.Proposer]) != nil {
+		faults := append([]pkg.Fault{{Type: pkg.InvalidProposal, Offender: fractal.Proposer, FractalID: fractal.ID}}, pkg.InvalidApprovals(certificate)...)
+		if err := system.slash(fractal, faults...); err != nil {
+			return err
 		}
 	}
 
-	system.banTraders(accepted, rejected)
-	if len(rejected) > len(accepted) {
+	if err := system.banTraders(minority(certificate)); err != nil {
+		return err
+	}
+	if !certificate.Accepted {
 		return errors.New("fractal ring verification failed")
 	}
+	fractal.Certificates = append(fractal.Certificates, certificate)
 	return nil
 }
 
 func (system *System) checkCoins(fractal *pkg.FractalRing) error {
+	used := make(map[string]bool)
 	for _, ring := range fractal.CooperationRings {
 		for _, coinID := range ring.CoinIDs {
 			if coin, ok := system.Coins[coinID]; !ok {
 				return errors.New("coin not found")
 			} else if coin.Status != pkg.Run {
 				return errors.New("coin is not running")
+			} else if used[coinID] {
+				return errors.New("coin is used twice")
 			}
+			used[coinID] = true
 		}
 	}
 	return nil
@@ -171,48 +277,216 @@ func (system *System) informOthers(fractal *pkg.FractalRing) error {
 			system.Coins[coinID] = coin
 		}
 	}
-	for _, trader := range system.Traders {
-		if err := trader.InformFractalRing(*fractal); err != nil {
+	return system.broadcast(pkg.Message{Kind: pkg.FractalMessage, From: fractal.Proposer, Fractal: fractal})
+}
+
+func (system *System) collectVotes(request pkg.Message, team []string) (map[string]pkg.Message, error) {
+	if system.Params.CommitReveal {
+		return system.collectCommittedVotes(request, team)
+	}
+
+	var err error
+	result := make(map[string]pkg.Message)
+	for _, traderID := range team {
+		if !system.isActive(traderID) {
+			continue
+		}
+		request.To = traderID
+		replies, e := system.exchange(request)
+		if e != nil && err == nil {
+			err = e
+		}
+		if reply, ok := repliesFrom(replies)[traderID]; ok {
+			result[traderID] = reply
+			if pkg.VerifyVotes(reply.Votes, system.publicKey) == nil {
+				request.Votes = append(request.Votes, reply.Votes...)
+			}
+		}
+	}
+	return result, err
+}
+
+func (system *System) collectCommittedVotes(request pkg.Message, team []string) (map[string]pkg.Message, error) {
+	messages := make([]pkg.Message, 0, len(team))
+	for _, traderID := range team {
+		if system.isActive(traderID) {
+			request.To = traderID
+			messages = append(messages, request)
+		}
+	}
+	replies, err := system.exchange(messages...)
+	commitments := repliesFrom(replies)
+
+	var faults []pkg.Fault
+	result := make(map[string]pkg.Message)
+	reveal := pkg.Message{Kind: pkg.RevealMessage, Fractal: request.Fractal, Round: pkg.VerificationRound}
+	if request.Kind == pkg.RoundMessage {
+		reveal.Round = request.Fractal.Round
+	}
+	for _, traderID := range team {
+		commitment := commitments[traderID].Commitment
+		if _, ok := result[traderID]; ok || commitment == nil || commitment.Voter != traderID || commitment.Verify(system.publicKey(traderID)) != nil {
+			continue
+		}
+
+		reveal.To = traderID
+		replies, e := system.exchange(reveal)
+		if e != nil && err == nil {
+			err = e
+		}
+		reply, ok := repliesFrom(replies)[traderID]
+		if !ok || commitment.Opens(reply.Votes, reply.Nonce) != nil || pkg.VerifyVotes(reply.Votes, system.publicKey) != nil {
+			faults = append(faults, pkg.Fault{Type: pkg.Unrevealed, Offender: traderID, FractalID: request.Fractal.ID, Commitment: commitment})
+			continue
+		}
+		result[traderID] = reply
+		reveal.Votes = append(reveal.Votes, reply.Votes...)
+	}
+
+	if e := system.slash(nil, faults...); e != nil && err == nil {
+		err = e
+	}
+	return result, err
+}
+
+func (system *System) castVotes(request pkg.Message, team []string) ([]pkg.Vote, error) {
+	replies, err := system.collectVotes(request, team)
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []pkg.Vote
+	for _, traderID := range team {
+		reply, ok := replies[traderID]
+		if !ok || pkg.VerifyVotes(reply.Votes, system.publicKey) != nil {
+			continue
+		}
+		for _, vote := range reply.Votes {
+			if vote.Voter == traderID {
+				votes = append(votes, vote)
+			}
+		}
+	}
+
+	equivocations := pkg.FindEquivocations(votes)
+	if len(equivocations) == 0 {
+		return votes, nil
+	}
+	system.Equivocations = append(system.Equivocations, equivocations...)
+
+	equivocators := make(map[string]bool)
+	faults := make([]pkg.Fault, 0, len(equivocations))
+	for _, equivocation := range equivocations {
+		equivocators[equivocation.First.Voter] = true
+		faults = append(faults, pkg.Fault{Type: pkg.Equivocating, Offender: equivocation.First.Voter, FractalID: equivocation.First.FractalID, Equivocation: &equivocation})
+	}
+	votes = slices.DeleteFunc(votes, func(vote pkg.Vote) bool {
+		return equivocators[vote.Voter]
+	})
+	return votes, system.slash(nil, faults...)
+}
+
+func (system *System) slash(fractal *pkg.FractalRing, faults ...pkg.Fault) error {
+	for _, fault := range faults {
+		trader, ok := system.Traders[fault.Offender]
+		if !ok || trader.Data == nil {
+			continue
+		}
+
+		amount := min(system.Params.SlashAmount(fault.Type), max(system.balanceOf(fault.Offender), 0))
+		if system.Supply != nil && system.isActive(fault.Offender) {
+			system.Supply.Slashed += amount
+		}
+		system.Slashes = append(system.Slashes, Slash{Fault: fault, Behavior: trader.Data.TraderType, Amount: amount, Time: system.Scheduler.Clock})
+		if err := system.broadcast(pkg.Message{Kind: pkg.SlashMessage, Fault: &fault, Fractal: fractal}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (system *System) runFractal(fractal *pkg.FractalRing) error {
-	for round := 0; round < pkg.RoundsCount; round++ {
-		for index, ring := range fractal.CooperationRings {
-			if ring.Rounds == -1 {
-				accepted, rejected := []string{}, []string{}
-				for _, traderID := range fractal.VerificationTeam {
-					if err := system.Traders[traderID].Vote(); err != nil {
-						if err.Error() != "bad behavior" {
-							return err
-						}
-						rejected = append(rejected, traderID)
-					} else {
-						accepted = append(accepted, traderID)
-					}
+func (system *System) publicKey(traderID string) *tools.PublicKey {
+	if trader, ok := system.Traders[traderID]; ok {
+		return trader.PublicKey
+	}
+	return nil
+}
+
+func votesOn(votes []pkg.Vote, fractalID, ringID string, round int) (result []pkg.Vote) {
+	for _, vote := range votes {
+		if vote.FractalID == fractalID && vote.RingID == ringID && vote.Round == round {
+			result = append(result, vote)
+		}
+	}
+	return
+}
+
+func splitVotes(certificate pkg.Certificate) (accepted, rejected []string) {
+	accepted, rejected = []string{}, []string{}
+	for _, vote := range certificate.Votes {
+		if vote.Accept {
+			accepted = append(accepted, vote.Voter)
+		} else {
+			rejected = append(rejected, vote.Voter)
+		}
+	}
+	return
+}
+
+func (system *System) runRound(fractal *pkg.FractalRing, round int) error {
+	fractal.Round = round
+	votes, err := system.castVotes(pkg.Message{Kind: pkg.RoundMessage, Fractal: fractal}, fractal.VerificationTeam)
+	if err != nil {
+		return err
+	}
+
+	weights := fractal.Weights()
+	certificates := make(map[string]pkg.Certificate)
+	for index, ring := range fractal.CooperationRings {
+		if ring.Rounds == -1 {
+			certificate := pkg.NewCertificate(fractal.ID, ring.ID, round, fractal.VerificationTeam, votesOn(votes, fractal.ID, ring.ID, round), system.Params.Quorum, weights)
+			for _, vote := range certificate.Votes {
+				if !vote.Accept && vote.Reason != "bad behavior" {
+					return errors.New(vote.Reason)
 				}
+			}
 
-				system.banTraders(accepted, rejected)
-				if len(rejected) > len(accepted) {
-					ring.Rounds = round
-					fractal.CooperationRings[index] = ring
-					money := system.Coins[ring.CoinIDs[0]].Amount * float64(round) / pkg.RoundsCount
-					if err := system.applyRing(ring, money); err != nil {
-						return err
-					}
+			system.RoundDecisions++
+			if certificate.Accepted != certificate.CountAccepted(fractal.VerificationTeam) {
+				system.RoundFlips++
+			}
+			system.pending.AddVotes(certificate)
+			if err := system.banTraders(minority(certificate)); err != nil {
+				return err
+			}
+			if !certificate.Accepted {
+				ring.Rounds = round
+				fractal.CooperationRings[index] = ring
+				fractal.Certificates = append(fractal.Certificates, certificate)
+				money := system.Coins[ring.CoinIDs[0]].Amount * float64(round) / float64(system.Params.RoundsCount)
+				if err := system.applyRing(ring, money, &certificate); err != nil {
+					return err
 				}
 			}
+			certificates[ring.ID] = certificate
 		}
 	}
+	if err := system.updateReputations(); err != nil {
+		return err
+	}
+
+	if round+1 < system.Params.RoundsCount {
+		system.Scheduler.Schedule(system.Params.RoundLength, Event{Kind: RoundEvent, FractalID: fractal.ID, Round: round + 1})
+		return nil
+	}
 
 	for index, ring := range fractal.CooperationRings {
 		if ring.Rounds == -1 {
-			ring.Rounds = pkg.RoundsCount
+			ring.Rounds = system.Params.RoundsCount
 			fractal.CooperationRings[index] = ring
-			if err := system.applyRing(ring, system.Coins[ring.CoinIDs[0]].Amount); err != nil {
+			certificate := certificates[ring.ID]
+			fractal.Certificates = append(fractal.Certificates, certificate)
+			if err := system.applyRing(ring, system.Coins[ring.CoinIDs[0]].Amount, &certificate); err != nil {
 				return err
 			}
 		}
@@ -220,179 +494,262 @@ func (system *System) runFractal(fractal *pkg.FractalRing) error {
 	return nil
 }
 
-func (system *System) applyRing(ring pkg.CooperationTable, money float64) error {
+func (system *System) applyRing(ring pkg.CooperationTable, money float64, certificate *pkg.Certificate) error {
+	payments := make([]pkg.Payment, 0, len(ring.CoinIDs))
 	for _, coinID := range ring.CoinIDs {
 		coin := system.Coins[coinID]
-		amount := money * coin.Amount / ring.Weight
-		if ring.Rounds < pkg.RoundsCount {
+		payment := pkg.Payment{Owner: coin.Owner, Coin: coinID, Amount: money * coin.Amount / ring.Weight}
+		if ring.Rounds < system.Params.RoundsCount {
 			coin.Status = pkg.Expired
 		} else {
 			coin.Status = pkg.Paid
-			amount += pkg.FractalPrize
+			payment.Prize = system.Params.FractalPrize
 		}
 		system.Coins[coinID] = coin
-
-		for _, trader := range system.Traders {
-			if err := trader.UpdateBalance(coin.Owner, amount); err != nil {
-				return err
-			}
+		if system.Supply != nil {
+			system.Supply.Settled += coin.Amount
+		}
+		if system.isActive(coin.Owner) {
+			payments = append(payments, payment)
 		}
 	}
-
-	for _, trader := range system.Traders {
-		if ring.Rounds < pkg.RoundsCount {
-			trader.ExpireRing(ring)
-		} else {
-			trader.PayRing(ring)
+	if system.Supply != nil {
+		for _, payment := range payments {
+			system.Supply.Payouts += payment.Amount
+			system.Supply.Prizes += payment.Prize
 		}
 	}
-	return nil
-}
 
-func (system *System) banTraders(accepted, rejected []string) {
-	minority := accepted
-	if len(accepted) > len(rejected) {
-		minority = rejected
-	}
-	for _, traderID := range minority {
-		system.Traders[traderID].Data.BanUntil = system.FractalCounter + pkg.BanCount
+	paid := ring.Rounds >= system.Params.RoundsCount
+	message := pkg.Message{Kind: pkg.PayoutMessage, Ring: &ring, Payments: payments, OK: paid, Certificate: certificate}
+	if fractal, ok := system.Fractals[ring.FractalID]; ok {
+		message.From = fractal.Proposer
 	}
+	return system.broadcast(message)
 }
 
-func (system *System) CreateRandomCoins(trader *pkg.Trader, done <-chan bool, errors chan<- error) {
-	for {
-		select {
-		case <-done:
-			return
-		case <-trader.Data.Ticker.C:
-			amount := rand.Float64() * 10
-			if trader.Account < amount {
-				return
-			}
-
-			coinType := rand.Intn(int(trader.Data.CoinTypeCount))
-			if coin := trader.CreateCoin(amount, uint(coinType)); coin != nil {
-				if err := system.ProcessCoin(*coin); err != nil {
-					errors <- err
-				}
-			}
+func minority(certificate pkg.Certificate) []string {
+	accepted, rejected := splitVotes(certificate)
+	if certificate.Weights != nil {
+		if certificate.Accepted {
+			return rejected
 		}
+		return accepted
+	} else if len(accepted) > len(rejected) {
+		return rejected
 	}
+	return accepted
 }
 
-func (system *System) Init(numTraders, numRandomVoters, numBadVoters int, coinTypeCount uint) error {
-	ch := make(chan bool)
-	for i := 0; i < numTraders; i++ {
-		go func() {
-			var trader *pkg.Trader
-			amount, wallet := rand.Float64()*1000, uuid.New().String()
-			if i < numRandomVoters {
-				trader = pkg.CreateTrader(pkg.RandomVote, amount, wallet, coinTypeCount)
-			} else if i < numRandomVoters+numBadVoters {
-				trader = pkg.CreateTrader(pkg.BadVote, amount, wallet, coinTypeCount)
-			} else {
-				trader = pkg.CreateTrader(pkg.Normal, amount, wallet, coinTypeCount)
-			}
+func (system *System) voteWeigher() func(traderID string) float64 {
+	return pkg.VoteWeigher(&system.Params, system.balanceOf, system.Coins)
+}
 
-			system.Locker.Lock()
-			defer system.Locker.Unlock()
-			system.Traders[trader.ID] = trader
-			ch <- true
-		}()
+func (system *System) banTraders(minority []string) error {
+	system.MinorityVotes += len(minority)
+	if !system.Params.BanMinority {
+		return nil
 	}
-	log.Printf("%d traders created: %d random voters, %d bad voters\n", numTraders, numRandomVoters, numBadVoters)
-	for i := 0; i < numTraders; i++ {
-		<-ch
+	system.pending.AddBans(minority)
+	messages := make([]pkg.Message, 0, len(minority))
+	for _, traderID := range minority {
+		messages = append(messages, pkg.Message{Kind: pkg.BanMessage, To: traderID, Round: system.FractalCounter + system.Params.BanCount})
 	}
-	return system.saveTraders()
+	_, err := system.exchange(messages...)
+	return err
 }
 
-func (system *System) saveTraders() error {
-	for _, trader1 := range system.Traders {
-		for _, trader2 := range system.Traders {
-			if err := trader1.SaveTrader(*trader2); err != nil {
-				return err
-			}
-		}
+func (system *System) updateReputations() error {
+	if len(system.pending) == 0 {
+		return nil
 	}
-	return nil
+	updates := system.pending
+	system.pending = make(pkg.Reputations)
+	system.Reputations.Merge(updates)
+	return system.broadcast(pkg.Message{Kind: pkg.ReputationMessage, Reputations: updates})
 }
 
-func (system *System) Start(finish <-chan bool) {
-	errors := make(chan error)
-	dones := make([]chan bool, len(system.Traders))
+func (system *System) teamWeights() map[string]float64 {
+	return pkg.TeamWeights(&system.Params, system.traderIDs, func(traderID string) pkg.Reputation {
+		return system.Reputations[traderID]
+	})
+}
 
-	i, finished := 0, 0
-	for _, trader := range system.Traders {
-		dones[i] = make(chan bool, 1)
-		go func(index int) {
-			system.CreateRandomCoins(trader, dones[index], errors)
-			finished++
-		}(i)
-		i++
+func (system *System) badSeats(team []string) (seats int) {
+	for _, traderID := range team {
+		if trader, ok := system.Traders[traderID]; ok && trader.Data != nil && trader.Data.TraderType != pkg.Normal {
+			seats++
+		}
 	}
+	return
+}
 
-	for finished < len(system.Traders) {
-		select {
-		case err := <-errors:
-			if Debug {
-				log.Println("Error:", err)
-				if err.Error() != "bad behavior" {
-					syscall.Exit(1)
-				}
-			}
-		case <-finish:
-			i := 0
-			for _, trader := range system.Traders {
-				trader.Data.Ticker.Stop()
-				dones[i] <- true
-				i++
-			}
+func (system *System) CreateRandomCoin(trader *pkg.Trader) (bool, error) {
+	replies, err := system.exchange(pkg.Message{Kind: pkg.MintMessage, To: trader.ID, Time: system.Scheduler.Clock})
+	minted, ok := repliesFrom(replies)[trader.ID]
+	if !ok {
+		return false, err
+	}
+	if minted.Coin != nil {
+		return true, system.ProcessCoin(*minted.Coin, err)
+	}
+	return minted.OK, err
+}
 
-			log.Println("Waiting for traders to finish...")
-			time.Sleep(10 * time.Second)
-			return
+func (system *System) Init(numTraders, numRandomVoters, numBadVoters, numColluders, numCopycats int, coinTypeCount uint) error {
+	system.CoinTypeCount = coinTypeCount
+	for i := 0; i < numTraders; i++ {
+		behavior := pkg.Normal
+		if i < numRandomVoters {
+			behavior = pkg.RandomVote
+		} else if i < numRandomVoters+numBadVoters {
+			behavior = pkg.BadVote
+		} else if i < numRandomVoters+numBadVoters+numColluders {
+			behavior = pkg.Colluder
+		} else if i < numRandomVoters+numBadVoters+numColluders+numCopycats {
+			behavior = pkg.Copycat
 		}
+
+		trader, err := system.CreateTrader(behavior, system.Random.Float64()*1000)
+		if err != nil {
+			return err
+		}
+		system.Traders[trader.ID] = trader
+		system.register(trader)
+		if system.Supply != nil {
+			system.Supply.Deposits += trader.Account
+		}
+	}
+	system.traderIDs = slices.Sorted(maps.Keys(system.Traders))
+	for _, traderID := range system.traderIDs {
+		system.Scheduler.Schedule(system.Random.Int64N(system.Params.RoundLength), Event{Kind: CoinEvent, TraderID: traderID})
 	}
+	log.Printf("%d traders created: %d random voters, %d bad voters, %d colluders, %d copycats\n", numTraders, numRandomVoters, numBadVoters, numColluders, numCopycats)
+	return system.saveTraders()
 }
 
-func (system *System) Save(filePath string) error {
-	file, err := os.Create(filePath)
+func (system *System) CreateTrader(behavior pkg.BehaviorType, amount float64) (*pkg.Trader, error) {
+	wallet, err := uuid.NewRandomFromReader(system.Random)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	random := system.Random.Fork()
+	trader := pkg.CreateTrader(&system.Params, behavior, amount, wallet.String(), system.CoinTypeCount, random, random)
+	if trader == nil {
+		return nil, errors.New("trader creation failed")
+	}
+	if behavior == pkg.Colluder || behavior == pkg.Sybil {
+		system.Coalition.Add(trader.ID)
 	}
-	defer file.Close()
+	return trader, system.setStrategy(trader)
+}
 
-	data, err := json.Marshal(system)
+func (system *System) setStrategy(trader *pkg.Trader) error {
+	strategy, err := pkg.NewStrategy(trader.Data.TraderType, &system.Params)
 	if err != nil {
 		return err
 	}
+	if system.Coalition.Contains(trader.ID) {
+		strategy = pkg.CoalitionStrategy{Coalition: system.Coalition}
+	}
+	trader.Data.Strategy = strategy
+	return nil
+}
 
-	_, err = file.Write(data)
+func (system *System) saveTraders() error {
+	messages := make([]pkg.Message, 0, len(system.traderIDs)*len(system.traderIDs))
+	for _, trader1 := range system.sortedTraders() {
+		for _, trader2 := range system.sortedTraders() {
+			messages = append(messages, pkg.Message{Kind: pkg.JoinMessage, To: trader1.ID, Trader: announce(trader2)})
+		}
+	}
+	_, err := system.exchange(messages...)
 	return err
 }
 
-func Load(filePath string) (*System, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+func announce(trader *pkg.Trader) *pkg.Trader {
+	announced := *trader
+	announced.Data = nil
+	return &announced
+}
 
-	fileStatus, err := file.Stat()
-	if err != nil {
-		return nil, err
+func (system *System) sortedTraders() []*pkg.Trader {
+	traders := make([]*pkg.Trader, 0, len(system.traderIDs))
+	for _, traderID := range system.traderIDs {
+		traders = append(traders, system.Traders[traderID])
 	}
+	return traders
+}
 
-	data := make([]byte, fileStatus.Size())
-	n, err := file.Read(data)
-	if err != nil {
-		return nil, err
+func (system *System) Start(maxTicks int64, maxCoins int) {
+	system.Horizon, system.CoinLimit, system.CoinCount = maxTicks, maxCoins, 0
+	system.Scheduler.Resume()
+	system.Continue()
+}
+
+func (system *System) Continue() {
+	for {
+		event, ok := system.Scheduler.Next()
+		if !ok {
+			return
+		}
+
+		if event.Kind == CoinEvent || event.Kind == SybilEvent || event.Kind == ChurnEvent || event.Kind == ExpiryEvent {
+			limited := event.Kind != ExpiryEvent && system.CoinLimit > 0 && system.CoinCount >= system.CoinLimit
+			if (system.Horizon > 0 && event.Time > system.Horizon) || limited {
+				system.Scheduler.Defer(event)
+				continue
+			}
+			if event.Kind == CoinEvent {
+				system.CoinCount++
+			}
+		}
+		if err := system.handleEvent(event); err != nil {
+			system.reportError(err)
+		}
+		if err := system.checkInvariants(event); err != nil {
+			log.Fatalln("Invariant check failed:", err)
+		}
+		if err := system.checkpoint(); err != nil {
+			log.Println("Error saving checkpoint:", err)
+		}
 	}
+}
 
-	system := NewSystem()
-	if err := json.Unmarshal(data[:n], system); err != nil {
-		return nil, err
+func (system *System) handleEvent(event Event) error {
+	switch event.Kind {
+	case CoinEvent:
+		if !system.isActive(event.TraderID) {
+			return nil
+		}
+		trader := system.Traders[event.TraderID]
+		ok, err := system.CreateRandomCoin(trader)
+		if ok {
+			system.Scheduler.Schedule(system.Params.RoundLength, Event{Kind: CoinEvent, TraderID: trader.ID})
+		}
+		return err
+	case CheckEvent:
+		return system.processTradersForCoin(event.TraderID)
+	case RoundEvent:
+		return system.runRound(system.Fractals[event.FractalID], event.Round)
+	case SybilEvent:
+		return system.mintSybils(event.Round)
+	case ChurnEvent:
+		return system.applyChurn()
+	case NetworkEvent:
+		return system.deliver(*event.Message)
+	case ExpiryEvent:
+		return system.RefundCoin(event.CoinID)
+	}
+	return errors.New("unknown event kind")
+}
+
+func (system *System) reportError(err error) {
+	if system.Params.Debug {
+		log.Println("Error:", err)
+		if err.Error() != "bad behavior" {
+			syscall.Exit(1)
+		}
 	}
-	return system, nil
 }
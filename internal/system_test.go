@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSystemsWithTheSameSeedCreateTheSameTraders(t *testing.T) {
+	first, second := newTestSystem(t, 5), newTestSystem(t, 5)
+	ids := slices.Sorted(maps.Keys(first.Traders))
+	if !slices.Equal(ids, slices.Sorted(maps.Keys(second.Traders))) {
+		t.Fatal("expected the same seed to create the same traders")
+	}
+	for _, traderID := range ids {
+		if !first.Traders[traderID].PublicKey.Equal(second.Traders[traderID].PublicKey) {
+			t.Fatalf("expected trader %s to get the same key from the same seed", traderID)
+		}
+	}
+
+	if system := NewSystem(0, first.Params); system.Seed == 0 {
+		t.Fatal("expected a random seed to be drawn for seed 0")
+	}
+}
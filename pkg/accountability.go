@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/tools"
+	"golang.org/x/exp/maps"
+)
+
+type FaultType int
+
+const (
+	Equivocating FaultType = iota
+	InvalidProposal
+	InvalidApproval
+	Unrevealed
+	DoubleSpend
+)
+
+type Fault struct {
+	Type         FaultType     `json:"type"`
+	Offender     string        `json:"offender"`
+	FractalID    string        `json:"fractal_id,omitempty"`
+	Vote         *Vote         `json:"vote,omitempty"`
+	Equivocation *Equivocation `json:"equivocation,omitempty"`
+	Commitment   *Commitment   `json:"commitment,omitempty"`
+	Coins        []CoinTable   `json:"coins,omitempty"`
+}
+
+func (f FaultType) String() string {
+	switch f {
+	case Equivocating:
+		return "equivocation"
+	case InvalidProposal:
+		return "invalid proposal"
+	case InvalidApproval:
+		return "invalid approval"
+	case Unrevealed:
+		return "unrevealed vote"
+	case DoubleSpend:
+		return "double spend"
+	}
+	return "unknown fault"
+}
+
+func (p Params) SlashAmount(faultType FaultType) float64 {
+	switch faultType {
+	case Equivocating:
+		return p.SlashEquivocation
+	case InvalidProposal:
+		return p.SlashInvalidProposal
+	case InvalidApproval:
+		return p.SlashInvalidApproval
+	case Unrevealed:
+		return p.SlashNoReveal
+	case DoubleSpend:
+		return p.SlashDoubleSpend
+	}
+	return 0
+}
+
+func CheckFractalRing(params *Params, fractal *FractalRing, traders []string, weights map[string]float64, publicKey *tools.PublicKey, counter int) error {
+	selectedRings := make([]string, 0, len(fractal.CooperationRings))
+	for _, cooperation := range fractal.CooperationRings {
+		selectedRings = append(selectedRings, cooperation.ID)
+	}
+
+	ringSeed, teamSeed, err := VerifySelection(fractal, publicKey, counter)
+	if err != nil {
+		return err
+	}
+
+	if len(selectedRings) == 0 || len(fractal.VerificationTeam) == 0 {
+		return errors.New("empty fractal ring")
+	} else if fractal.ID != tools.SHA256Str(selectedRings) {
+		return errors.New("invalid fractal ring id")
+	} else if !reflect.DeepEqual(selectedRings, selectFractalRing(params, fractal.SoloRings, firstPick(fractal.SoloRings, ringSeed))) {
+		return errors.New("invalid selected cooperation ring")
+	} else if !reflect.DeepEqual(fractal.VerificationTeam, selectVerificationTeam(params, traders, selectedRings, firstPick(traders, teamSeed), weights)) {
+		return errors.New("invalid verification team")
+	}
+	return nil
+}
+
+func InvalidApprovals(certificate Certificate) []Fault {
+	var faults []Fault
+	for _, vote := range certificate.Votes {
+		if vote.Accept {
+			faults = append(faults, Fault{Type: InvalidApproval, Offender: vote.Voter, FractalID: certificate.FractalID, Vote: &vote})
+		}
+	}
+	return faults
+}
+
+func (t *Trader) checkFractalRing(fractal *FractalRing) error {
+	traders := maps.Keys(t.Data.Traders)
+	slices.Sort(traders)
+	proposer := t.Data.Traders[fractal.Proposer]
+	return CheckFractalRing(t.Data.Params, fractal, traders, t.teamWeights(traders), proposer.PublicKey, t.proposalCounter(fractal.Proposer))
+}
+
+func (t *Trader) VerifyFault(fault Fault, fractal *FractalRing) error {
+	offender, ok := t.Data.Traders[fault.Offender]
+	if !ok {
+		return errors.New("trader not found")
+	}
+
+	switch fault.Type {
+	case Equivocating:
+		if fault.Equivocation == nil || fault.Equivocation.First.Voter != fault.Offender {
+			return errors.New("missing equivocation")
+		}
+		return fault.Equivocation.Verify(offender.PublicKey)
+	case InvalidProposal:
+		if fractal == nil || fractal.ID != fault.FractalID || fractal.Proposer != fault.Offender {
+			return errors.New("fault does not match fractal ring")
+		}
+	case InvalidApproval:
+		vote := fault.Vote
+		if fractal == nil || fractal.ID != fault.FractalID || vote == nil {
+			return errors.New("fault does not match fractal ring")
+		} else if vote.Voter != fault.Offender || vote.FractalID != fractal.ID || vote.Round != VerificationRound || !vote.Accept {
+			return errors.New("vote is not an approval of fractal ring")
+		} else if err := VerifyVotes([]Vote{*vote}, func(string) *tools.PublicKey { return offender.PublicKey }); err != nil {
+			return err
+		}
+	case Unrevealed:
+		return errors.New("unrevealed vote cannot be proven")
+	case DoubleSpend:
+		if len(fault.Coins) != 2 {
+			return errors.New("missing double spend")
+		}
+		first, second := fault.Coins[0], fault.Coins[1]
+		if first.Owner != fault.Offender || second.Owner != fault.Offender || first.Sequence != second.Sequence || first.ID == second.ID {
+			return errors.New("coins are not a double spend")
+		}
+		for _, coin := range fault.Coins {
+			if err := tools.VerifyWithPublicKeyStr(coin.message(), coin.ID, offender.PublicKey); err != nil {
+				return errors.New("invalid coin id")
+			}
+		}
+		return nil
+	default:
+		return errors.New("unknown fault")
+	}
+
+	if t.checkFractalRing(fractal) == nil {
+		return errors.New("fractal ring is valid")
+	}
+	return nil
+}
+
+func (t *Trader) Slash(fault Fault) {
+	ref := fault.Type.String() + "-" + fault.FractalID
+	if fault.Type == DoubleSpend {
+		if !t.saveConflict(fault.Coins) {
+			return
+		}
+		ref = fmt.Sprintf("%s-%s-%d", fault.Type, fault.Offender, fault.Coins[0].Sequence)
+	}
+
+	amount := t.Data.Params.SlashAmount(fault.Type)
+	if trader, ok := t.Data.Traders[fault.Offender]; ok {
+		t.post(fault.Offender, SlashEntry, -min(amount, max(trader.Account, 0)), ref)
+	}
+	if fault.Offender == t.ID {
+		t.Account -= min(amount, max(t.Account, 0))
+	}
+}
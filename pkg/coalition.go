@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"errors"
+	"slices"
+)
+
+type Coalition struct {
+	Members map[string]bool `json:"members"`
+}
+
+func NewCoalition() *Coalition {
+	return &Coalition{
+		Members: make(map[string]bool),
+	}
+}
+
+func (c *Coalition) Add(traderID string) {
+	c.Members[traderID] = true
+}
+
+func (c *Coalition) Contains(traderID string) bool {
+	return c.Members[traderID]
+}
+
+func (c *Coalition) Seats(team []string) (seats int) {
+	for _, traderID := range team {
+		if c.Contains(traderID) {
+			seats++
+		}
+	}
+	return
+}
+
+type CoalitionStrategy struct {
+	Coalition *Coalition
+}
+
+func (s CoalitionStrategy) ProposeFractal(t *Trader, soloRings []string) ([]string, bool) {
+	return proposeFractal(t, soloRings, true)
+}
+
+func (s CoalitionStrategy) SelectTeam(t *Trader, traders []string, ring []string) ([]string, bool) {
+	var bestTeam []string
+	bestSeats := -1
+	for _, traderID := range traders {
+		if !s.Coalition.Contains(traderID) {
+			continue
+		}
+
+		team := selectVerificationTeam(t.Data.Params, traders, ring, traderID, t.teamWeights(traders))
+		if seats := s.Coalition.Seats(team); seats > bestSeats {
+			bestTeam, bestSeats = team, seats
+		}
+	}
+
+	honestTeam, _ := selectTeam(t, traders, ring, false)
+	if bestTeam == nil {
+		return honestTeam, true
+	}
+	return bestTeam, slices.Equal(bestTeam, honestTeam)
+}
+
+func (s CoalitionStrategy) VerifyVote(t *Trader, fractal *FractalRing, err error) error {
+	if !s.Coalition.Contains(fractal.Proposer) {
+		return errors.New("bad behavior")
+	} else if err != nil && !IsDisputableError(err) {
+		return err
+	}
+	return nil
+}
+
+func (s CoalitionStrategy) RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error {
+	if s.Coalition.Contains(fractal.Proposer) {
+		return nil
+	}
+	for _, coinID := range ring.CoinIDs {
+		if s.Coalition.Contains(t.Data.Coins[coinID].Owner) {
+			return nil
+		}
+	}
+	return errors.New("bad behavior")
+}
@@ -3,6 +3,9 @@ package pkg
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 
 	"github.com/Arka-Lab/LoR/tools"
 )
@@ -14,6 +17,9 @@ const (
 	Blocked
 	Expired
 	Paid
+	Withdrawn
+	Refunded
+	Conflicted
 )
 
 type CoinTable struct {
@@ -25,25 +31,50 @@ type CoinTable struct {
 	Prev   string  `json:"prev"`
 	Owner  string  `json:"owner"`
 
+	Sequence uint64 `json:"sequence"`
+	Created  int64  `json:"created"`
+	Expires  int64  `json:"expires,omitempty"`
+
 	CooperationID string
 }
 
-func (t *Trader) CreateCoin(amount float64, coinType uint) *CoinTable {
-	if t.Account < amount {
+func (c CoinTable) message() string {
+	message := fmt.Sprintf("%s-%d-%d-%s-%d", c.Owner, c.Type, c.Sequence, strconv.FormatFloat(c.Amount, 'g', -1, 64), c.Created)
+	if c.Expires != 0 {
+		message += fmt.Sprintf("-%d", c.Expires)
+	}
+	return message
+}
+
+func (p Params) coinExpiry(created int64) int64 {
+	if p.CoinTTL == 0 {
+		return 0
+	}
+	return created + p.CoinTTL
+}
+
+func (t *Trader) CreateCoin(amount float64, coinType uint, created int64) *CoinTable {
+	if t.balance() < amount {
 		return nil
 	}
-	id, err := tools.SignWithPrivateKeyStr(t.ID+"-"+fmt.Sprint(coinType), t.Data.PrivateKey)
+
+	coin := &CoinTable{
+		Amount:   amount,
+		Status:   Run,
+		Type:     coinType,
+		Owner:    t.ID,
+		Sequence: t.Sequence + 1,
+		Created:  created,
+		Expires:  t.Data.Params.coinExpiry(created),
+	}
+	id, err := tools.SignWithPrivateKeyStr(t.Data.Random, coin.message(), t.Data.PrivateKey, t.Data.Params.IDEncoding)
 	if err != nil {
 		return nil
 	}
 
-	return &CoinTable{
-		ID:     id,
-		Amount: amount,
-		Status: Run,
-		Type:   coinType,
-		Owner:  t.ID,
-	}
+	coin.ID = id
+	t.Sequence = coin.Sequence
+	return coin
 }
 
 func (t *Trader) SaveCoin(coin CoinTable) error {
@@ -55,20 +86,187 @@ func (t *Trader) SaveCoin(coin CoinTable) error {
 		return errors.New("trader not found")
 	} else if trader.Account < coin.Amount {
 		return errors.New("insufficient account")
-	} else if err := tools.VerifyWithPublicKeyStr(coin.Owner+"-"+fmt.Sprint(coin.Type), coin.ID, trader.PublicKey); err != nil {
+	} else if err := tools.VerifyWithPublicKeyStr(coin.message(), coin.ID, trader.PublicKey); err != nil {
 		return errors.New("invalid coin id")
+	} else if coin.Expires != t.Data.Params.coinExpiry(coin.Created) {
+		return errors.New("invalid coin expiry")
 	} else if coin.Next != "" || coin.Prev != "" {
 		return errors.New("coin is already in a ring")
-	} else if _, ok := t.Data.Coins[coin.ID]; ok {
+	} else if t.knowsCoin(coin.ID) {
 		return errors.New("coin already exist")
+	} else if t.conflictingCoin(coin) != nil {
+		return errors.New("coin sequence already used")
+	} else if coin.Sequence <= trader.Sequence {
+		return errors.New("coin sequence out of order")
+	} else if coin.Sequence > trader.Sequence+1 {
+		if t.Data.pending == nil {
+			t.Data.pending = make(map[string]CoinTable)
+		}
+		t.Data.pending[coin.ID] = coin
+		t.indexCoin(coin)
+		return nil
 	}
 
 	trader := t.Data.Traders[coin.Owner]
-	trader.Account -= coin.Amount
+	trader.Sequence = coin.Sequence
+	t.Data.Traders[coin.Owner] = trader
+	t.post(coin.Owner, MintEntry, -coin.Amount, coin.ID)
 	t.Data.Coins[coin.ID] = coin
+	t.indexCoin(coin)
+	return t.savePendingCoins(coin.Owner)
+}
+
+func (t *Trader) savePendingCoins(owner string) error {
+	trader, ok := t.Data.Traders[owner]
+	if !ok {
+		return nil
+	}
+	for _, coinID := range slices.Sorted(maps.Keys(t.Data.pending)) {
+		coin := t.Data.pending[coinID]
+		if coin.Owner != owner {
+			continue
+		} else if coin.Sequence <= trader.Sequence {
+			delete(t.Data.pending, coinID)
+			t.unindexCoin(coin)
+		} else if coin.Sequence == trader.Sequence+1 {
+			delete(t.Data.pending, coinID)
+			return t.SaveCoin(coin)
+		}
+	}
+	return nil
+}
+
+func (t *Trader) missingCoin(owner string) *CoinTable {
+	trader, ok := t.Data.Traders[owner]
+	if !ok || t.Data.Params.ResendAfter == 0 {
+		return nil
+	}
+	buffered := 0
+	for _, coin := range t.Data.pending {
+		if coin.Owner == owner {
+			buffered++
+		}
+	}
+	if buffered < t.Data.Params.ResendAfter {
+		return nil
+	}
+	return &CoinTable{Owner: owner, Sequence: trader.Sequence + 1}
+}
+
+func (t *Trader) mintedCoin(sequence uint64) *CoinTable {
+	coin, ok := t.Data.Coins[t.sequenceIndex()[t.ID][sequence]]
+	if !ok || coin.Status != Run || t.Data.Cooperations[coin.CooperationID].FractalID != "" {
+		return nil
+	}
+	coin.Next, coin.Prev, coin.CooperationID = "", "", ""
+	return &coin
+}
+
+func (t *Trader) knowsCoin(coinID string) bool {
+	_, saved := t.Data.Coins[coinID]
+	_, pending := t.Data.pending[coinID]
+	return saved || pending
+}
+
+func (t *Trader) sequenceIndex() map[string]map[uint64]string {
+	if t.Data.sequences == nil {
+		t.Data.sequences = make(map[string]map[uint64]string)
+		for _, coins := range []map[string]CoinTable{t.Data.Coins, t.Data.pending} {
+			for _, coinID := range slices.Sorted(maps.Keys(coins)) {
+				coin := coins[coinID]
+				if t.Data.sequences[coin.Owner] == nil {
+					t.Data.sequences[coin.Owner] = make(map[uint64]string)
+				}
+				if _, ok := t.Data.sequences[coin.Owner][coin.Sequence]; !ok {
+					t.Data.sequences[coin.Owner][coin.Sequence] = coinID
+				}
+			}
+		}
+	}
+	return t.Data.sequences
+}
+
+func (t *Trader) indexCoin(coin CoinTable) {
+	sequences := t.sequenceIndex()
+	if sequences[coin.Owner] == nil {
+		sequences[coin.Owner] = make(map[uint64]string)
+	}
+	if _, ok := sequences[coin.Owner][coin.Sequence]; !ok {
+		sequences[coin.Owner][coin.Sequence] = coin.ID
+	}
+}
+
+func (t *Trader) unindexCoin(coin CoinTable) {
+	if sequences := t.sequenceIndex()[coin.Owner]; sequences[coin.Sequence] == coin.ID {
+		delete(sequences, coin.Sequence)
+	}
+}
+
+func (t *Trader) conflictingCoin(coin CoinTable) *CoinTable {
+	coinID, ok := t.sequenceIndex()[coin.Owner][coin.Sequence]
+	if !ok || coinID == coin.ID {
+		return nil
+	} else if other, ok := t.Data.Coins[coinID]; ok {
+		return &other
+	} else if other, ok := t.Data.pending[coinID]; ok {
+		return &other
+	}
 	return nil
 }
 
+func (t *Trader) FindDoubleSpend(coin CoinTable) *Fault {
+	other := t.conflictingCoin(coin)
+	if other == nil {
+		return nil
+	}
+	trader, ok := t.Data.Traders[coin.Owner]
+	if !ok || tools.VerifyWithPublicKeyStr(coin.message(), coin.ID, trader.PublicKey) != nil {
+		return nil
+	}
+	return &Fault{Type: DoubleSpend, Offender: coin.Owner, Coins: []CoinTable{*other, coin}}
+}
+
+func (t *Trader) saveConflict(coins []CoinTable) bool {
+	saved := false
+	for _, coin := range coins {
+		if !t.knowsCoin(coin.ID) {
+			coin.Status = Conflicted
+			t.Data.Coins[coin.ID] = coin
+			t.indexCoin(coin)
+			saved = true
+		}
+	}
+	return saved
+}
+
+func (t *Trader) RefundCoin(coinID string, now int64) error {
+	coin, ok := t.Data.Coins[coinID]
+	if !ok {
+		return errors.New("coin not found")
+	} else if coin.Status != Run {
+		return errors.New("coin is not running")
+	} else if coin.Expires == 0 || now < coin.Expires {
+		return errors.New("coin has not expired")
+	}
+
+	t.leaveRing(coin)
+	coin = t.Data.Coins[coinID]
+	coin.Status = Refunded
+	t.Data.Coins[coinID] = coin
+	t.post(coin.Owner, RefundEntry, coin.Amount, coin.ID)
+	return nil
+}
+
+func (t *Trader) ExpiredCoins(now int64) (coins []CoinTable) {
+	for _, coinID := range slices.Sorted(maps.Keys(t.Data.Coins)) {
+		coin := t.Data.Coins[coinID]
+		if coin.Owner == t.ID && coin.Status == Run && coin.Expires != 0 && coin.Expires <= now {
+			coins = append(coins, coin)
+		}
+	}
+	return
+}
+
 func (t *Trader) UpdateCoin(coin CoinTable) error {
 	if _, ok := t.Data.Coins[coin.ID]; !ok {
 		return errors.New("coin not found")
@@ -0,0 +1,244 @@
+package pkg
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func newTestTraders(t *testing.T, scheme tools.SignatureScheme, count int) []*Trader {
+	t.Helper()
+	params := DefaultParams()
+	params.SignatureScheme, params.KeySize = scheme, 1024
+	return newTestTradersWith(t, params, count)
+}
+
+func newTestTradersWith(t *testing.T, params Params, count int) []*Trader {
+	t.Helper()
+	traders := make([]*Trader, count)
+	for i := range traders {
+		random := tools.NewRandom(uint64(i + 1))
+		traders[i] = CreateTrader(&params, Normal, 1000, "wallet-"+strconv.Itoa(i), 2, random, random)
+		if traders[i] == nil {
+			t.Fatalf("trader %d creation failed", i)
+		}
+	}
+	for _, trader := range traders {
+		for _, other := range traders {
+			if err := trader.SaveTrader(*other); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return traders
+}
+
+func createTestCoin(t *testing.T, trader *Trader, amount float64, coinType uint, created int64) CoinTable {
+	t.Helper()
+	coin := trader.CreateCoin(amount, coinType, created)
+	if coin == nil {
+		t.Fatal("coin creation failed")
+	}
+	return *coin
+}
+
+func expectError(t *testing.T, err error, message string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected %q, got no error", message)
+	} else if err.Error() != message {
+		t.Fatalf("expected %q, got %q", message, err.Error())
+	}
+}
+
+func TestCoinIDsAreUniquePerCoin(t *testing.T) {
+	for _, scheme := range []tools.SignatureScheme{tools.RSAPSS, tools.Ed25519} {
+		t.Run(string(scheme), func(t *testing.T) {
+			traders := newTestTraders(t, scheme, 2)
+			owner, receiver := traders[0], traders[1]
+
+			seen := make(map[string]bool)
+			for i := 1; i <= 5; i++ {
+				coin := createTestCoin(t, owner, 5, 0, 100)
+				if coin.Sequence != uint64(i) {
+					t.Fatalf("expected sequence %d, got %d", i, coin.Sequence)
+				} else if seen[coin.ID] {
+					t.Fatalf("duplicate coin ID %s", coin.ID)
+				}
+				seen[coin.ID] = true
+				if err := receiver.SaveCoin(coin); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if sequence := receiver.Data.Traders[owner.ID].Sequence; sequence != 5 {
+				t.Fatalf("expected last sequence 5, got %d", sequence)
+			}
+		})
+	}
+}
+
+func TestSaveCoinRejectsReplay(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 1, 100)
+	if err := receiver.SaveCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, receiver.SaveCoin(coin), "coin already exist")
+}
+
+func TestSaveCoinBuffersSequenceGaps(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 2)
+	owner, receiver := traders[0], traders[1]
+
+	first := createTestCoin(t, owner, 5, 0, 100)
+	second := createTestCoin(t, owner, 5, 0, 200)
+	third := createTestCoin(t, owner, 5, 0, 300)
+	for _, coin := range []CoinTable{third, second} {
+		if err := receiver.SaveCoin(coin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(receiver.Data.Coins) != 0 || receiver.Data.Traders[owner.ID].Sequence != 0 {
+		t.Fatalf("expected coins after a gap to wait, got %d coins", len(receiver.Data.Coins))
+	}
+	expectError(t, receiver.SaveCoin(second), "coin already exist")
+
+	if err := receiver.SaveCoin(first); err != nil {
+		t.Fatal(err)
+	} else if len(receiver.Data.Coins) != 3 {
+		t.Fatalf("expected the gap to release all coins, got %d coins", len(receiver.Data.Coins))
+	} else if sequence := receiver.Data.Traders[owner.ID].Sequence; sequence != 3 {
+		t.Fatalf("expected last sequence 3, got %d", sequence)
+	} else if account := receiver.Data.Traders[owner.ID].Account; account != 985 {
+		t.Fatalf("expected account 985, got %.2f", account)
+	}
+}
+
+func TestSaveCoinRejectsDoubleSpend(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	owner.Sequence--
+	substitute := createTestCoin(t, owner, 9, 1, 100)
+	if coin.Sequence != substitute.Sequence || coin.ID == substitute.ID {
+		t.Fatal("expected two different coins with the same sequence")
+	}
+
+	if err := receiver.SaveCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, receiver.SaveCoin(substitute), "coin sequence already used")
+	fault := receiver.FindDoubleSpend(substitute)
+	if fault == nil || fault.Type != DoubleSpend || fault.Offender != owner.ID {
+		t.Fatalf("expected a double spend by the owner, got %v", fault)
+	} else if err := receiver.VerifyFault(*fault, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := *fault
+	forged.Coins = []CoinTable{coin, coin}
+	expectError(t, receiver.VerifyFault(forged, nil), "coins are not a double spend")
+	forged.Coins = []CoinTable{coin, substitute}
+	forged.Coins[1].Amount = 1
+	expectError(t, receiver.VerifyFault(forged, nil), "invalid coin id")
+
+	receiver.ApplyView(owner.View())
+	if receiver.Data.sequences != nil {
+		t.Fatal("expected applying a view to drop the sequence index")
+	} else if fault := receiver.FindDoubleSpend(substitute); fault == nil {
+		t.Fatal("expected the rebuilt sequence index to find the double spend")
+	}
+}
+
+func TestSaveCoinRejectsTamperedCoin(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 3)
+	owner, receiver, forger := traders[0], traders[1], traders[2]
+
+	tests := []struct {
+		name   string
+		tamper func(*CoinTable)
+	}{
+		{"amount", func(coin *CoinTable) { coin.Amount = 50 }},
+		{"type", func(coin *CoinTable) { coin.Type = 1 }},
+		{"sequence", func(coin *CoinTable) { coin.Sequence += 10 }},
+		{"created", func(coin *CoinTable) { coin.Created = 0 }},
+		{"expires", func(coin *CoinTable) { coin.Expires = 500 }},
+		{"owner", func(coin *CoinTable) { coin.Owner = forger.ID }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			coin := createTestCoin(t, owner, 5, 0, 100)
+			test.tamper(&coin)
+			expectError(t, receiver.SaveCoin(coin), "invalid coin id")
+		})
+	}
+
+	forged := createTestCoin(t, forger, 5, 0, 100)
+	forged.Owner = owner.ID
+	expectError(t, receiver.SaveCoin(forged), "invalid coin id")
+}
+
+func TestRefundCoinAfterExpiry(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.CoinTTL = tools.Ed25519, 50
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	for _, trader := range traders {
+		if err := trader.SaveCoin(coin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectError(t, receiver.RefundCoin(coin.ID, 149), "coin has not expired")
+	if expired := receiver.ExpiredCoins(150); len(expired) != 0 {
+		t.Fatalf("expected no expired coins of the receiver, got %d", len(expired))
+	} else if expired := owner.ExpiredCoins(150); len(expired) != 1 || expired[0].ID != coin.ID {
+		t.Fatalf("expected the coin to expire for its owner, got %v", expired)
+	}
+
+	if err := receiver.RefundCoin(coin.ID, 150); err != nil {
+		t.Fatal(err)
+	} else if status := receiver.Data.Coins[coin.ID].Status; status != Refunded {
+		t.Fatalf("expected refunded coin, got status %d", status)
+	} else if account := receiver.Data.Traders[owner.ID].Account; account != 1000 {
+		t.Fatalf("expected account 1000 after the refund, got %.2f", account)
+	}
+	expectError(t, receiver.RefundCoin(coin.ID, 200), "coin is not running")
+}
+
+func TestCooperationRingSkipsRefundedCoins(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.CoinTTL = tools.Ed25519, 50
+	traders := newTestTradersWith(t, params, 2)
+	owner, proposer := traders[0], traders[1]
+
+	refunded := createTestCoin(t, owner, 5, 0, 100)
+	other := createTestCoin(t, owner, 5, 1, 100)
+	for _, coin := range []CoinTable{refunded, other} {
+		if err := proposer.SaveCoin(coin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := proposer.RefundCoin(refunded.ID, 150); err != nil {
+		t.Fatal(err)
+	} else if ring := proposer.checkForCooperationRing(); ring != nil {
+		t.Fatalf("expected no cooperation ring from a refunded coin, got %v", ring.CoinIDs)
+	}
+
+	running := createTestCoin(t, owner, 5, 0, 200)
+	if err := proposer.SaveCoin(running); err != nil {
+		t.Fatal(err)
+	}
+	ring := proposer.checkForCooperationRing()
+	if ring == nil || !slices.Equal(ring.CoinIDs, []string{running.ID, other.ID}) {
+		t.Fatalf("expected a ring of the running coins, got %v", ring)
+	} else if err := proposer.validateCooperationRing(*ring); err != nil {
+		t.Fatal(err)
+	}
+}
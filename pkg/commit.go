@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type Commitment struct {
+	Voter     string `json:"voter"`
+	FractalID string `json:"fractal_id"`
+	Round     int    `json:"round"`
+	Hash      string `json:"hash"`
+	Signature string `json:"signature"`
+}
+
+type committed struct {
+	Votes []Vote `json:"votes"`
+	Nonce string `json:"nonce"`
+}
+
+func commitmentKey(fractalID string, round int) string {
+	return fmt.Sprintf("%s/%d", fractalID, round)
+}
+
+func commitmentHash(votes []Vote, nonce string) string {
+	if len(votes) == 0 {
+		return tools.SHA256Str("empty-" + nonce)
+	}
+	return tools.SHA256Str(votes[0].Root + "-" + nonce)
+}
+
+func (c Commitment) digest() string {
+	return tools.SHA256Str(fmt.Sprintf("commit-%s-%s-%d-%s", c.Voter, c.FractalID, c.Round, c.Hash))
+}
+
+func (c Commitment) Verify(publicKey *tools.PublicKey) error {
+	if publicKey == nil {
+		return errors.New("voter not found")
+	} else if err := tools.VerifyWithPublicKeyStr(c.digest(), c.Signature, publicKey); err != nil {
+		return errors.New("invalid commitment signature")
+	}
+	return nil
+}
+
+func (c Commitment) Opens(votes []Vote, nonce string) error {
+	for _, vote := range votes {
+		if vote.Voter != c.Voter || vote.FractalID != c.FractalID || vote.Round != c.Round {
+			return errors.New("revealed vote does not match commitment")
+		} else if vote.Root != votes[0].Root {
+			return errors.New("revealed votes are not signed together")
+		}
+	}
+	if commitmentHash(votes, nonce) != c.Hash {
+		return errors.New("revealed votes do not match commitment")
+	}
+	return nil
+}
+
+func (t *Trader) commit(fractalID string, round int, votes []Vote) (Commitment, error) {
+	nonce := strconv.FormatUint(t.Data.Random.Uint64(), 16)
+	commitment := Commitment{Voter: t.ID, FractalID: fractalID, Round: round, Hash: commitmentHash(votes, nonce)}
+	signature, err := tools.SignWithPrivateKeyStr(t.Data.Random, commitment.digest(), t.Data.PrivateKey, t.Data.Params.IDEncoding)
+	if err != nil {
+		return commitment, err
+	}
+	commitment.Signature = signature
+
+	if t.Data.commitments == nil {
+		t.Data.commitments = make(map[string]committed)
+	}
+	t.Data.commitments[commitmentKey(fractalID, round)] = committed{Votes: votes, Nonce: nonce}
+	return commitment, nil
+}
+
+func (t *Trader) reveal(fractalID string, round int, observed []Vote) ([]Vote, string, bool) {
+	key := commitmentKey(fractalID, round)
+	pending, ok := t.Data.commitments[key]
+	if !ok {
+		return nil, "", false
+	}
+	delete(t.Data.commitments, key)
+
+	if follower, ok := t.Data.Strategy.(Follower); ok && !follower.Reveal(t, pending.Votes, observed) {
+		return nil, "", false
+	}
+	return pending.Votes, pending.Nonce, true
+}
@@ -6,12 +6,6 @@ import (
 	"slices"
 
 	"github.com/Arka-Lab/LoR/tools"
-	"golang.org/x/exp/rand"
-)
-
-const (
-	RoundsCount = 10
-	RoundLength = 1000
 )
 
 type CooperationTable struct {
@@ -31,10 +25,13 @@ type CooperationTable struct {
 func (t *Trader) checkForCooperationRing() *CooperationTable {
 	unusedCoins := make([][]string, t.Data.CoinTypeCount)
 	for _, coin := range t.Data.Coins {
-		if coin.Prev == "" && coin.Next == "" {
+		if coin.Status == Run && coin.Prev == "" && coin.Next == "" {
 			unusedCoins[coin.Type] = append(unusedCoins[coin.Type], coin.ID)
 		}
 	}
+	for _, coins := range unusedCoins {
+		slices.Sort(coins)
+	}
 
 	for _, coins := range unusedCoins {
 		if len(coins) == 0 {
@@ -44,7 +41,7 @@ func (t *Trader) checkForCooperationRing() *CooperationTable {
 
 	isValid := true
 	var selectedCoins []string
-	selectedCoins = selectCooperationRing(unusedCoins, "")
+	selectedCoins = selectCooperationRing(t.Data.Random, unusedCoins, "")
 
 	cooperationID := tools.SHA256Str(selectedCoins)
 	for i, coinID := range selectedCoins {
@@ -92,26 +89,26 @@ func (t *Trader) validateCooperationRing(cooperation CooperationTable) error {
 		}
 	}
 
-	expectedRing := selectCooperationRing(cooperation.UnusedCoins, cooperation.Investor)
+	expectedRing := selectCooperationRing(t.Data.Random, cooperation.UnusedCoins, cooperation.Investor)
 	if !reflect.DeepEqual(expectedRing, cooperation.CoinIDs) {
 		return errors.New("invalid cooperation ring coins")
 	}
 	return nil
 }
 
-func selectRandomCooperation(unusedCoins [][]string) []string {
+func selectRandomCooperation(random *tools.Random, unusedCoins [][]string) []string {
 	selectedRing := make([]string, len(unusedCoins))
 	for i := 0; i < len(unusedCoins); i++ {
-		selectedRing[i] = unusedCoins[i][rand.Intn(len(unusedCoins[i]))]
+		selectedRing[i] = unusedCoins[i][random.IntN(len(unusedCoins[i]))]
 	}
 	return selectedRing
 }
 
-func selectCooperationRing(unusedCoins [][]string, investor string) []string {
+func selectCooperationRing(random *tools.Random, unusedCoins [][]string, investor string) []string {
 	rnd := make([]int, 0)
 	selectedRing := make([]string, len(unusedCoins))
 	if investor == "" {
-		selectedRing[0] = unusedCoins[0][rand.Intn(len(unusedCoins[0]))]
+		selectedRing[0] = unusedCoins[0][random.IntN(len(unusedCoins[0]))]
 	} else {
 		selectedRing[0] = investor
 	}
@@ -119,23 +116,41 @@ func selectCooperationRing(unusedCoins [][]string, investor string) []string {
 		if len(rnd) == 0 {
 			rnd = tools.SHA256Arr(selectedRing)
 		}
-		slices.Sort(unusedCoins[i])
-		rnd, selectedRing[i] = rnd[1:], unusedCoins[i][rnd[0]%len(unusedCoins[i])]
+		coins := unusedCoins[i]
+		if !slices.IsSorted(coins) {
+			coins = slices.Sorted(slices.Values(coins))
+		}
+		rnd, selectedRing[i] = rnd[1:], coins[rnd[0]%len(coins)]
 	}
 	return selectedRing
 }
 
 func (t *Trader) ExpireRing(ring CooperationTable) {
 	for _, coinID := range ring.CoinIDs {
-		coin := t.Data.Coins[coinID]
+		coin, ok := t.Data.Coins[coinID]
+		if !ok {
+			continue
+		}
 		coin.Status = Expired
 		t.Data.Coins[coinID] = coin
 	}
 }
 
+func (t *Trader) ringSettled(ring CooperationTable) bool {
+	for _, coinID := range ring.CoinIDs {
+		if coin, ok := t.Data.Coins[coinID]; ok && (coin.Status == Paid || coin.Status == Expired) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Trader) PayRing(ring CooperationTable) {
 	for _, coinID := range ring.CoinIDs {
-		coin := t.Data.Coins[coinID]
+		coin, ok := t.Data.Coins[coinID]
+		if !ok {
+			continue
+		}
 		coin.Status = Paid
 		t.Data.Coins[coinID] = coin
 	}
@@ -2,29 +2,34 @@ package pkg
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 
 	"github.com/Arka-Lab/LoR/tools"
 	"golang.org/x/exp/maps"
-	"golang.org/x/exp/rand"
-)
-
-const (
-	FractalMin   = 50
-	FractalMax   = 200
-	FractalPrize = 5
 )
 
 type FractalRing struct {
 	ID               string             `json:"id"`
 	CooperationRings []CooperationTable `json:"cooperation_rings"`
 	VerificationTeam []string           `json:"verification_team"`
+	Proposer         string             `json:"proposer"`
+	Round            int                `json:"round"`
+	Counter          int                `json:"counter"`
+	Proof            string             `json:"proof,omitempty"`
+	Certificates     []Certificate      `json:"certificates,omitempty"`
 
 	SoloRings []string `json:"-"`
 	IsValid   bool
 }
 
+type selectionDraw struct {
+	Counter int    `json:"counter"`
+	Output  string `json:"output"`
+	Proof   string `json:"proof"`
+}
+
 func (t *Trader) checkForFractalRing() *FractalRing {
 	soloRings := t.getSoloRings()
 
@@ -39,6 +44,11 @@ func (t *Trader) checkForFractalRing() *FractalRing {
 		return nil
 	}
 
+	_, proof, err := t.drawSelection()
+	if err != nil {
+		return nil
+	}
+
 	fractalID := tools.SHA256Str(selectedRing)
 	selectedCooperations := t.updateCooperations(selectedRing, fractalID, &isValid)
 
@@ -48,6 +58,9 @@ func (t *Trader) checkForFractalRing() *FractalRing {
 		CooperationRings: selectedCooperations,
 		SoloRings:        soloRings,
 		VerificationTeam: team,
+		Proposer:         t.ID,
+		Counter:          t.proposalCounter(t.ID),
+		Proof:            proof,
 	}
 }
 
@@ -58,24 +71,26 @@ func (t *Trader) getSoloRings() []string {
 			soloRings = append(soloRings, cooperation.ID)
 		}
 	}
+	slices.Sort(soloRings)
 	return soloRings
 }
 
 func (t *Trader) getSelectedRing(soloRings []string, isValid *bool) []string {
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
+	selectedRing, valid := t.Data.Strategy.ProposeFractal(t, soloRings)
+	if !valid {
 		*isValid = false
-		return selectRandomFractal(soloRings)
 	}
-	return selectFractalRing(soloRings, "")
+	return selectedRing
 }
 
 func (t *Trader) getVerificationTeam(selectedRing []string, isValid *bool) []string {
 	traders := maps.Keys(t.Data.Traders)
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
+	slices.Sort(traders)
+	team, valid := t.Data.Strategy.SelectTeam(t, traders, selectedRing)
+	if !valid {
 		*isValid = false
-		return selectRandomVerification(traders)
 	}
-	return selectVerificationTeam(traders, selectedRing, "")
+	return team
 }
 
 func (t *Trader) updateCooperations(selectedRing []string, fractalID string, isValid *bool) []CooperationTable {
@@ -104,38 +119,100 @@ func (t *Trader) validateFractalRing(fractal *FractalRing) error {
 	}
 	traders := maps.Keys(t.Data.Traders)
 
+	var publicKey *tools.PublicKey
+	if proposer, ok := t.Data.Traders[fractal.Proposer]; ok {
+		publicKey = proposer.PublicKey
+	}
+	ringSeed, teamSeed, err := VerifySelection(fractal, publicKey, t.proposalCounter(fractal.Proposer))
+	if err != nil {
+		return err
+	}
+
 	if fractal.ID != tools.SHA256Str(selectedRings) {
 		return errors.New("invalid fractal ring id")
-	} else if !reflect.DeepEqual(selectedRings, selectFractalRing(fractal.SoloRings, selectedRings[0])) {
+	} else if !reflect.DeepEqual(selectedRings, selectFractalRing(t.Data.Params, fractal.SoloRings, firstPick(fractal.SoloRings, ringSeed))) {
 		return errors.New("invalid selected cooperation ring")
-	} else if !reflect.DeepEqual(fractal.VerificationTeam, selectVerificationTeam(traders, selectedRings, fractal.VerificationTeam[0])) {
+	} else if !reflect.DeepEqual(fractal.VerificationTeam, selectVerificationTeam(t.Data.Params, traders, selectedRings, firstPick(traders, teamSeed), t.teamWeights(traders))) {
 		return errors.New("invalid verification team")
 	}
 	return nil
 }
 
-func selectRandomFractal(soloRings []string) (result []string) {
-	if len(soloRings) < FractalMin {
+func selectionInput(proposer string, counter int) string {
+	return fmt.Sprintf("selection-%s-%d", proposer, counter)
+}
+
+func selectionSeeds(output string) (ringSeed, teamSeed int) {
+	seeds := tools.SHA256Arr(output)
+	return seeds[0], seeds[1]
+}
+
+func firstPick(candidates []string, seed int) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	sorted := slices.Sorted(slices.Values(candidates))
+	return sorted[seed%len(sorted)]
+}
+
+func VerifySelection(fractal *FractalRing, publicKey *tools.PublicKey, counter int) (ringSeed, teamSeed int, err error) {
+	if fractal.Counter != counter {
+		return 0, 0, errors.New("invalid proposal counter")
+	}
+	output, err := tools.VRFVerify(publicKey, selectionInput(fractal.Proposer, fractal.Counter), fractal.Proof)
+	if err != nil {
+		return 0, 0, errors.New("invalid vrf proof")
+	}
+	ringSeed, teamSeed = selectionSeeds(output)
+	return
+}
+
+func (t *Trader) proposalCounter(traderID string) int {
+	return t.Data.Traders[traderID].Certified
+}
+
+func (t *Trader) drawSelection() (output string, proof string, err error) {
+	counter := t.proposalCounter(t.ID)
+	if draw := t.Data.draw; draw != nil && draw.Counter == counter {
+		return draw.Output, draw.Proof, nil
+	}
+	output, proof, err = tools.VRFProve(t.Data.PrivateKey, selectionInput(t.ID, counter))
+	if err == nil {
+		t.Data.draw = &selectionDraw{Counter: counter, Output: output, Proof: proof}
+	}
+	return
+}
+
+func (t *Trader) selectionSeeds() (ringSeed, teamSeed int) {
+	output, _, err := t.drawSelection()
+	if err != nil {
+		return 0, 0
+	}
+	return selectionSeeds(output)
+}
+
+func selectRandomFractal(params *Params, random *tools.Random, soloRings []string) (result []string) {
+	if len(soloRings) < params.FractalMin {
 		return nil
 	}
 
-	k := FractalMin + tools.SHA256Int(soloRings)%(FractalMax-FractalMin+1)
+	k := params.FractalMin + tools.SHA256Int(soloRings)%(params.FractalMax-params.FractalMin+1)
 	if len(soloRings) < k {
 		return nil
 	}
 
-	for _, index := range tools.RandomIndexes(len(soloRings), k) {
+	for _, index := range tools.RandomIndexes(random, len(soloRings), k) {
 		result = append(result, soloRings[index])
 	}
 	return
 }
 
-func selectFractalRing(soloRings []string, firstRing string) (result []string) {
-	if len(soloRings) < FractalMin {
+func selectFractalRing(params *Params, soloRings []string, firstRing string) (result []string) {
+	if len(soloRings) < params.FractalMin {
 		return nil
 	}
 
-	k := FractalMin + tools.SHA256Int(soloRings)%(FractalMax-FractalMin+1)
+	k := params.FractalMin + tools.SHA256Int(soloRings)%(params.FractalMax-params.FractalMin+1)
 	if len(soloRings) < k {
 		return nil
 	}
@@ -145,21 +222,13 @@ func selectFractalRing(soloRings []string, firstRing string) (result []string) {
 	copy(copiedRings, soloRings)
 	slices.Sort(copiedRings)
 
-	if firstRing != "" {
-		result[0] = firstRing
-		for i := 0; i < len(copiedRings); i++ {
-			if copiedRings[i] == firstRing {
-				copiedRings[i] = copiedRings[0]
-				copiedRings = copiedRings[1:]
-				break
-			}
+	result[0] = firstRing
+	for i := 0; i < len(copiedRings); i++ {
+		if copiedRings[i] == firstRing {
+			copiedRings[i] = copiedRings[0]
+			copiedRings = copiedRings[1:]
+			break
 		}
-	} else {
-		index := rand.Intn(len(soloRings))
-		result[0] = copiedRings[index]
-
-		copiedRings[index] = copiedRings[0]
-		copiedRings = copiedRings[1:]
 	}
 
 	rnd := make([]int, 0)
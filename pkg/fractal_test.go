@@ -0,0 +1,36 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func TestSelectionCounterIgnoresReputationUpdates(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 2)
+	proposer, verifier := traders[0], traders[1]
+
+	_, proof, err := proposer.drawSelection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fractal := &FractalRing{Proposer: proposer.ID, Counter: proposer.proposalCounter(proposer.ID), Proof: proof}
+	if _, _, err := VerifySelection(fractal, proposer.PublicKey, verifier.proposalCounter(proposer.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier.UpdateReputations(Reputations{proposer.ID: {Proposals: 3, Accepted: 3}})
+	if _, _, err := VerifySelection(fractal, proposer.PublicKey, verifier.proposalCounter(proposer.ID)); err != nil {
+		t.Fatalf("expected reputation updates to leave the counter alone, got %v", err)
+	}
+
+	entry := verifier.Data.Traders[proposer.ID]
+	entry.Certified++
+	verifier.Data.Traders[proposer.ID] = entry
+	_, _, err = VerifySelection(fractal, proposer.PublicKey, verifier.proposalCounter(proposer.ID))
+	expectError(t, err, "invalid proposal counter")
+
+	fractal.Counter++
+	_, _, err = VerifySelection(fractal, proposer.PublicKey, verifier.proposalCounter(proposer.ID))
+	expectError(t, err, "invalid vrf proof")
+}
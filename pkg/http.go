@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const MessagePath = "/message"
+
+type HTTPTransport struct {
+	Client *http.Client
+
+	local    *ChannelTransport
+	locker   sync.RWMutex
+	peers    map[string]string
+	outboxes map[string]chan Message
+	pending  sync.WaitGroup
+}
+
+func NewHTTPTransport() *HTTPTransport {
+	return &HTTPTransport{
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		local:    NewChannelTransport(),
+		peers:    make(map[string]string),
+		outboxes: make(map[string]chan Message),
+	}
+}
+
+func (t *HTTPTransport) Register(id string, handler func(Message)) {
+	t.local.Register(id, handler)
+}
+
+func (t *HTTPTransport) Unregister(id string) {
+	t.local.Unregister(id)
+}
+
+func (t *HTTPTransport) AddPeer(id, address string) {
+	t.RemovePeer(id)
+
+	inbox, outbox := make(chan Message), make(chan Message)
+	go forward(inbox, outbox)
+	go func() {
+		for message := range outbox {
+			if err := t.post(address, message); err != nil && message.To != SystemID {
+				t.local.Send(Message{Kind: ErrorMessage, From: message.To, To: SystemID, Error: err.Error()})
+			}
+			t.pending.Done()
+		}
+	}()
+
+	t.locker.Lock()
+	t.peers[id] = address
+	t.outboxes[id] = inbox
+	t.locker.Unlock()
+}
+
+func (t *HTTPTransport) RemovePeer(id string) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+	if outbox, ok := t.outboxes[id]; ok {
+		close(outbox)
+		delete(t.outboxes, id)
+		delete(t.peers, id)
+	}
+}
+
+func (t *HTTPTransport) Peers() map[string]string {
+	t.locker.RLock()
+	defer t.locker.RUnlock()
+	peers := make(map[string]string, len(t.peers))
+	for id, address := range t.peers {
+		peers[id] = address
+	}
+	return peers
+}
+
+func (t *HTTPTransport) Send(message Message) error {
+	if err := t.local.Send(message); err == nil {
+		return nil
+	}
+
+	t.locker.RLock()
+	defer t.locker.RUnlock()
+	outbox, ok := t.outboxes[message.To]
+	if !ok {
+		return errors.New("unknown recipient")
+	}
+	t.pending.Add(1)
+	outbox <- message
+	return nil
+}
+
+func (t *HTTPTransport) Flush() {
+	t.pending.Wait()
+	t.local.Flush()
+}
+
+func (t *HTTPTransport) Close() {
+	t.Flush()
+	t.locker.Lock()
+	for id, outbox := range t.outboxes {
+		close(outbox)
+		delete(t.outboxes, id)
+		delete(t.peers, id)
+	}
+	t.locker.Unlock()
+	t.local.Close()
+}
+
+func (t *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var message Message
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if message.From == SystemID || !message.Kind.Remote() {
+		http.Error(w, "message kind is local", http.StatusForbidden)
+		return
+	} else if err := t.local.Send(message); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (t *HTTPTransport) post(address string, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	response, err := t.Client.Post(address+MessagePath, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("peer %s answered with status %d", message.To, response.StatusCode)
+	}
+	return nil
+}
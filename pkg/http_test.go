@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPTransportAcceptsOnlyPeerMessages(t *testing.T) {
+	transport := NewHTTPTransport()
+	defer transport.Close()
+	received := make(chan Message, 1)
+	transport.Register("node", func(message Message) {
+		received <- message
+	})
+
+	post := func(message Message) int {
+		data, err := json.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		transport.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, MessagePath, bytes.NewReader(data)))
+		return recorder.Code
+	}
+
+	for _, kind := range []MessageKind{MintMessage, CheckMessage, RejectMessage, JoinMessage, LeaveMessage, SyncMessage, ViewMessage, ErrorMessage} {
+		if code := post(Message{Kind: kind, From: "peer", To: "node", View: &View{}}); code != http.StatusForbidden {
+			t.Fatalf("expected message kind %d to be refused, got status %d", kind, code)
+		}
+	}
+	if code := post(Message{Kind: CoinMessage, From: SystemID, To: "node"}); code != http.StatusForbidden {
+		t.Fatalf("expected system messages to be refused, got status %d", code)
+	} else if code := post(Message{Kind: CoinMessage, From: "peer", To: "node"}); code != http.StatusAccepted {
+		t.Fatalf("expected a coin message to be accepted, got status %d", code)
+	} else if message := <-received; message.Kind != CoinMessage {
+		t.Fatalf("expected the coin message to be delivered, got kind %d", message.Kind)
+	}
+}
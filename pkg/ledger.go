@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type EntryKind string
+
+const (
+	OpenEntry   EntryKind = "open"
+	MintEntry   EntryKind = "mint"
+	PayEntry    EntryKind = "pay"
+	ExpiryEntry EntryKind = "expiry"
+	RefundEntry EntryKind = "refund"
+	PrizeEntry  EntryKind = "prize"
+	SlashEntry  EntryKind = "slash"
+)
+
+type LedgerEntry struct {
+	Kind   EntryKind `json:"kind"`
+	Amount float64   `json:"amount"`
+	Ref    string    `json:"ref,omitempty"`
+	Hash   string    `json:"hash"`
+}
+
+type Ledger map[string][]LedgerEntry
+
+type Divergence struct {
+	Account string       `json:"account"`
+	Index   int          `json:"index"`
+	Common  string       `json:"common,omitempty"`
+	First   *LedgerEntry `json:"first,omitempty"`
+	Second  *LedgerEntry `json:"second,omitempty"`
+}
+
+func (e LedgerEntry) digest(account, previous string) string {
+	return tools.SHA256Str(fmt.Sprintf("%s-%s-%s-%s-%s", previous, account, e.Kind, strconv.FormatFloat(e.Amount, 'g', -1, 64), e.Ref))
+}
+
+func (l Ledger) Head(account string) string {
+	if entries := l[account]; len(entries) > 0 {
+		return entries[len(entries)-1].Hash
+	}
+	return ""
+}
+
+func (l Ledger) Append(account string, kind EntryKind, amount float64, ref string) {
+	entry := LedgerEntry{Kind: kind, Amount: amount, Ref: ref}
+	entry.Hash = entry.digest(account, l.Head(account))
+	l[account] = append(l[account], entry)
+}
+
+func (l Ledger) Balance(account string) (balance float64) {
+	for _, entry := range l[account] {
+		balance += entry.Amount
+	}
+	return
+}
+
+func (l Ledger) Entries() (count int) {
+	for _, entries := range l {
+		count += len(entries)
+	}
+	return
+}
+
+func (l Ledger) Verify() error {
+	for account, entries := range l {
+		previous := ""
+		for _, entry := range entries {
+			if entry.digest(account, previous) != entry.Hash {
+				return fmt.Errorf("invalid ledger entry hash for %s", account)
+			}
+			previous = entry.Hash
+		}
+	}
+	return nil
+}
+
+func (l Ledger) Clone() Ledger {
+	if l == nil {
+		return nil
+	}
+	clone := make(Ledger, len(l))
+	for account, entries := range l {
+		clone[account] = slices.Clip(entries)
+	}
+	return clone
+}
+
+func CompareLedgers(first, second Ledger) (divergences []Divergence) {
+	accounts := slices.Sorted(maps.Keys(first))
+	for account := range second {
+		if _, ok := first[account]; !ok {
+			accounts = append(accounts, account)
+		}
+	}
+	slices.Sort(accounts)
+
+	for _, account := range accounts {
+		if divergence := diverge(account, first[account], second[account]); divergence != nil {
+			divergences = append(divergences, *divergence)
+		}
+	}
+	return
+}
+
+func diverge(account string, first, second []LedgerEntry) *Divergence {
+	length := min(len(first), len(second))
+	index := sort.Search(length, func(i int) bool {
+		return first[i].Hash != second[i].Hash
+	})
+	if index == length && len(first) == len(second) {
+		return nil
+	}
+
+	divergence := &Divergence{Account: account, Index: index}
+	if index > 0 {
+		divergence.Common = first[index-1].Hash
+	}
+	if index < len(first) {
+		divergence.First = &first[index]
+	}
+	if index < len(second) {
+		divergence.Second = &second[index]
+	}
+	return divergence
+}
+
+func (d Divergence) Verify() error {
+	if d.First == nil && d.Second == nil {
+		return errors.New("divergence has no entries")
+	} else if d.First != nil && d.Second != nil && d.First.Hash == d.Second.Hash {
+		return errors.New("entries do not diverge")
+	}
+	for _, entry := range []*LedgerEntry{d.First, d.Second} {
+		if entry != nil && entry.digest(d.Account, d.Common) != entry.Hash {
+			return errors.New("entry does not follow the common history")
+		}
+	}
+	return nil
+}
+
+func (t *Trader) post(account string, kind EntryKind, amount float64, ref string) {
+	trader, ok := t.Data.Traders[account]
+	if !ok {
+		return
+	}
+	trader.Account += amount
+	t.Data.Traders[account] = trader
+	if t.Data.Ledger != nil {
+		t.Data.Ledger.Append(account, kind, amount, ref)
+	}
+}
+
+func (t *Trader) SetLedger(enabled bool) {
+	if !enabled {
+		t.Data.Ledger = nil
+		return
+	} else if t.Data.Ledger != nil {
+		return
+	}
+
+	t.Data.Ledger = make(Ledger)
+	for _, traderID := range slices.Sorted(maps.Keys(t.Data.Traders)) {
+		t.Data.Ledger.Append(traderID, OpenEntry, t.Data.Traders[traderID].Account, "")
+	}
+}
+
+func (t *Trader) CheckLedger() error {
+	if t.Data.Ledger == nil {
+		return errors.New("ledger is disabled")
+	} else if err := t.Data.Ledger.Verify(); err != nil {
+		return err
+	}
+	for _, traderID := range slices.Sorted(maps.Keys(t.Data.Traders)) {
+		if balance := t.Data.Ledger.Balance(traderID); balance != t.Data.Traders[traderID].Account {
+			return fmt.Errorf("balance of %s is %v, but its ledger gives %v", traderID, t.Data.Traders[traderID].Account, balance)
+		}
+	}
+	return nil
+}
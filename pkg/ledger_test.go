@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func TestCompareLedgersFindsFirstDivergence(t *testing.T) {
+	first, second := make(Ledger), make(Ledger)
+	for _, ledger := range []Ledger{first, second} {
+		ledger.Append("a", OpenEntry, 100, "")
+		ledger.Append("a", MintEntry, -5, "coin-1")
+		ledger.Append("b", OpenEntry, 50, "")
+	}
+	first.Append("a", MintEntry, -7, "coin-2")
+	first.Append("a", PayEntry, 3, "coin-1")
+	second.Append("a", MintEntry, -9, "coin-3")
+
+	if divergences := CompareLedgers(first, first.Clone()); len(divergences) != 0 {
+		t.Fatalf("expected no divergence, got %v", divergences)
+	}
+	divergences := CompareLedgers(first, second)
+	if len(divergences) != 1 {
+		t.Fatalf("expected one divergence, got %d", len(divergences))
+	}
+	divergence := divergences[0]
+	if divergence.Account != "a" || divergence.Index != 2 || divergence.Common != first["a"][1].Hash {
+		t.Fatalf("unexpected divergence %+v", divergence)
+	} else if divergence.First.Ref != "coin-2" || divergence.Second.Ref != "coin-3" {
+		t.Fatalf("unexpected entries %+v and %+v", divergence.First, divergence.Second)
+	} else if err := divergence.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	divergence.First.Amount = -9
+	expectError(t, divergence.Verify(), "entry does not follow the common history")
+}
+
+func TestCompareLedgersFindsMissingEntries(t *testing.T) {
+	first, second := make(Ledger), make(Ledger)
+	first.Append("a", OpenEntry, 100, "")
+	second.Append("a", OpenEntry, 100, "")
+	second.Append("a", SlashEntry, -10, "equivocation-f")
+
+	divergences := CompareLedgers(first, second)
+	if len(divergences) != 1 || divergences[0].First != nil || divergences[0].Second == nil {
+		t.Fatalf("unexpected divergences %+v", divergences)
+	} else if err := divergences[0].Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLedgerDerivesBalances(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	if err := receiver.SaveCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.UpdateBalance(owner.ID, PayEntry, 2.5, coin.ID); err != nil {
+		t.Fatal(err)
+	}
+	receiver.Slash(Fault{Type: Equivocating, Offender: owner.ID})
+
+	if err := receiver.CheckLedger(); err != nil {
+		t.Fatal(err)
+	}
+	expected := 1000 - 5 + 2.5 - params.SlashEquivocation
+	if balance := receiver.Data.Ledger.Balance(owner.ID); balance != expected {
+		t.Fatalf("expected balance %v, got %v", expected, balance)
+	}
+	if entries := len(receiver.Data.Ledger[owner.ID]); entries != 4 {
+		t.Fatalf("expected 4 entries, got %d", entries)
+	}
+}
+
+func TestRemoveTraderRefundsRunningCoins(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	if err := receiver.SaveCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiver.RemoveTrader(owner.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := receiver.Data.Coins[coin.ID]; ok {
+		t.Fatal("expected the coin to leave with its owner")
+	}
+	entries := receiver.Data.Ledger[owner.ID]
+	if last := entries[len(entries)-1]; last.Kind != RefundEntry || last.Amount != 5 || last.Ref != coin.ID {
+		t.Fatalf("expected a refund of the coin, got %+v", last)
+	} else if balance := receiver.Data.Ledger.Balance(owner.ID); balance != 1000 {
+		t.Fatalf("expected the owner to leave with 1000, got %v", balance)
+	}
+}
@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type MessageKind int
+
+const (
+	MintMessage MessageKind = iota
+	MintedMessage
+	CoinMessage
+	CheckMessage
+	ProposalMessage
+	VerifyMessage
+	VoteMessage
+	RejectMessage
+	FractalMessage
+	RoundMessage
+	PayoutMessage
+	BanMessage
+	JoinMessage
+	LeaveMessage
+	SyncMessage
+	ViewMessage
+	ErrorMessage
+	SlashMessage
+	ReputationMessage
+	RevealMessage
+	RefundMessage
+	ResendMessage
+)
+
+const SystemID = "system"
+
+type Message struct {
+	Kind        MessageKind       `json:"kind"`
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Ref         string            `json:"ref,omitempty"`
+	Round       int               `json:"round,omitempty"`
+	Time        int64             `json:"time,omitempty"`
+	OK          bool              `json:"ok,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Coin        *CoinTable        `json:"coin,omitempty"`
+	Fractal     *FractalRing      `json:"fractal,omitempty"`
+	Ring        *CooperationTable `json:"ring,omitempty"`
+	Trader      *Trader           `json:"trader,omitempty"`
+	View        *View             `json:"view,omitempty"`
+	Payments    []Payment         `json:"payments,omitempty"`
+	Votes       []Vote            `json:"votes,omitempty"`
+	Certificate *Certificate      `json:"certificate,omitempty"`
+	Fault       *Fault            `json:"fault,omitempty"`
+	Reputations Reputations       `json:"reputations,omitempty"`
+	Commitment  *Commitment       `json:"commitment,omitempty"`
+	Nonce       string            `json:"nonce,omitempty"`
+	Signature   string            `json:"signature,omitempty"`
+}
+
+type Payment struct {
+	Owner  string  `json:"owner"`
+	Coin   string  `json:"coin,omitempty"`
+	Amount float64 `json:"amount"`
+	Prize  float64 `json:"prize,omitempty"`
+}
+
+type View struct {
+	Traders      map[string]Trader           `json:"traders"`
+	Coins        map[string]CoinTable        `json:"coins"`
+	Cooperations map[string]CooperationTable `json:"cooperations"`
+	Teams        map[string][]string         `json:"teams"`
+	Weights      map[string][]float64        `json:"weights,omitempty"`
+	Ledger       Ledger                      `json:"ledger,omitempty"`
+}
+
+func (kind MessageKind) Signed() bool {
+	return kind == BanMessage || kind == ReputationMessage
+}
+
+func (kind MessageKind) Remote() bool {
+	switch kind {
+	case CoinMessage, VerifyMessage, VoteMessage, FractalMessage, RoundMessage, PayoutMessage, BanMessage, SlashMessage, RefundMessage, ReputationMessage, RevealMessage, ResendMessage:
+		return true
+	}
+	return false
+}
+
+func (m Message) Digest() string {
+	reputations, _ := json.Marshal(m.Reputations)
+	return tools.SHA256Str(fmt.Sprintf("message-%d-%s-%s-%d-%s", m.Kind, m.From, m.To, m.Round, reputations))
+}
+
+func (t *Trader) SignMessage(message *Message) error {
+	message.From = t.ID
+	signature, err := tools.SignWithPrivateKeyStr(t.Data.Random, message.Digest(), t.Data.PrivateKey, t.Data.Params.IDEncoding)
+	if err != nil {
+		return err
+	}
+	message.Signature = signature
+	return nil
+}
+
+func (t *Trader) verifySender(message Message) error {
+	if message.From == SystemID {
+		return nil
+	} else if trader, ok := t.Data.Traders[message.From]; !ok {
+		return errors.New("sender not found")
+	} else if err := tools.VerifyWithPublicKeyStr(message.Digest(), message.Signature, trader.PublicKey); err != nil {
+		return errors.New("invalid message signature")
+	}
+	return nil
+}
+
+type wireMessage Message
+
+type wireEnvelope struct {
+	wireMessage
+	SoloRings   []string     `json:"solo_rings,omitempty"`
+	UnusedCoins [][][]string `json:"unused_coins,omitempty"`
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	envelope := wireEnvelope{wireMessage: wireMessage(m)}
+	if m.Fractal != nil {
+		envelope.SoloRings = m.Fractal.SoloRings
+		for _, cooperation := range m.Fractal.CooperationRings {
+			envelope.UnusedCoins = append(envelope.UnusedCoins, cooperation.UnusedCoins)
+		}
+	}
+	return json.Marshal(envelope)
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var envelope wireEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+
+	*m = Message(envelope.wireMessage)
+	if m.Fractal != nil {
+		m.Fractal.SoloRings = envelope.SoloRings
+		for i := range m.Fractal.CooperationRings {
+			if i < len(envelope.UnusedCoins) {
+				m.Fractal.CooperationRings[i].UnusedCoins = envelope.UnusedCoins[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"slices"
+
+	"golang.org/x/exp/maps"
+)
+
+type Node struct {
+	Trader    *Trader
+	Transport Transport
+}
+
+func NewNode(trader *Trader, transport Transport) *Node {
+	return &Node{
+		Trader:    trader,
+		Transport: transport,
+	}
+}
+
+func (n *Node) Handle(message Message) {
+	t := n.Trader
+	switch message.Kind {
+	case MintMessage:
+		n.mint(message)
+	case CoinMessage:
+		if t.knowsCoin(message.Coin.ID) {
+			return
+		} else if fault := t.FindDoubleSpend(*message.Coin); fault != nil {
+			n.broadcast(Message{Kind: SlashMessage, Fault: fault})
+			return
+		}
+		n.report(t.SaveCoin(*message.Coin))
+		if missing := t.missingCoin(message.Coin.Owner); missing != nil {
+			n.send(Message{Kind: ResendMessage, To: missing.Owner, Coin: missing})
+		}
+	case ResendMessage:
+		if coin := t.mintedCoin(message.Coin.Sequence); coin != nil {
+			n.reply(message, Message{Kind: CoinMessage, Coin: coin})
+		}
+	case CheckMessage:
+		n.reply(message, Message{Kind: ProposalMessage, Fractal: t.CheckForRings(message.Round)})
+	case VerifyMessage:
+		n.vote(message, []CooperationTable{{}}, func(CooperationTable) error {
+			return t.SubmitRing(message.Fractal)
+		})
+	case RejectMessage:
+		t.RemoveFractalRing(message.Fractal.ID)
+	case FractalMessage:
+		if _, ok := t.Data.Teams[message.Fractal.ID]; ok {
+			return
+		}
+		n.report(t.InformFractalRing(*message.Fractal))
+	case RoundMessage:
+		rings := message.Fractal.CooperationRings
+		if message.Ring != nil {
+			rings = []CooperationTable{*message.Ring}
+		}
+		n.vote(message, rings, func(ring CooperationTable) error {
+			return t.Vote(message.Fractal, ring)
+		})
+	case PayoutMessage:
+		if t.ringSettled(*message.Ring) {
+			return
+		}
+		if err := t.verifyPayout(*message.Ring, message.OK, message.Certificate, message.Payments); err != nil {
+			n.report(err)
+			return
+		}
+		kind := ExpiryEntry
+		if message.OK {
+			kind = PayEntry
+		}
+		for _, payment := range message.Payments {
+			if err := t.UpdateBalance(payment.Owner, kind, payment.Amount, payment.Coin); err != nil {
+				n.report(err)
+				return
+			}
+			if payment.Prize != 0 {
+				if err := t.UpdateBalance(payment.Owner, PrizeEntry, payment.Prize, payment.Coin); err != nil {
+					n.report(err)
+					return
+				}
+			}
+		}
+		if message.OK {
+			t.PayRing(*message.Ring)
+		} else {
+			t.ExpireRing(*message.Ring)
+		}
+	case BanMessage:
+		round, err := t.verifyBan(message)
+		if err != nil {
+			n.report(err)
+			return
+		}
+		t.Data.BanUntil = round
+	case JoinMessage:
+		n.report(t.SaveTrader(*message.Trader))
+	case LeaveMessage:
+		n.report(t.RemoveTrader(message.Trader.ID))
+	case SyncMessage:
+		view := t.View()
+		n.send(Message{Kind: ViewMessage, To: message.Trader.ID, View: &view})
+	case ViewMessage:
+		t.ApplyView(*message.View)
+	case SlashMessage:
+		if message.From != SystemID || message.Fault.Type != Unrevealed {
+			if err := t.VerifyFault(*message.Fault, message.Fractal); err != nil {
+				n.report(err)
+				return
+			}
+		}
+		t.Slash(*message.Fault)
+	case RefundMessage:
+		n.report(t.RefundCoin(message.Coin.ID, message.Time))
+	case ReputationMessage:
+		updates, err := t.verifyReputations(message)
+		if err != nil {
+			n.report(err)
+			return
+		}
+		t.UpdateReputations(updates)
+	case RevealMessage:
+		votes, nonce, _ := t.reveal(message.Fractal.ID, message.Round, message.Votes)
+		n.reply(message, Message{Kind: VoteMessage, OK: true, Votes: votes, Nonce: nonce})
+	}
+}
+
+func (n *Node) mint(message Message) {
+	t := n.Trader
+	amount := t.Data.Random.Float64() * 10
+	if t.balance() < amount {
+		n.reply(message, Message{Kind: MintedMessage, OK: false})
+		return
+	}
+
+	coinType := t.Data.Random.IntN(int(t.Data.CoinTypeCount))
+	coin := t.CreateCoin(amount, uint(coinType), message.Time)
+	n.reply(message, Message{Kind: MintedMessage, OK: true, Coin: coin})
+	if coin != nil {
+		n.broadcast(Message{Kind: CoinMessage, Coin: coin})
+	}
+}
+
+func (n *Node) broadcast(message Message) {
+	peers := maps.Keys(n.Trader.Data.Traders)
+	slices.Sort(peers)
+	for _, peerID := range peers {
+		message.To = peerID
+		n.send(message)
+	}
+}
+
+func (n *Node) vote(request Message, rings []CooperationTable, decide func(CooperationTable) error) {
+	reply := Message{Kind: VoteMessage, OK: true}
+	round := VerificationRound
+	if request.Kind == RoundMessage {
+		round = request.Fractal.Round
+	}
+	voted := make(map[string]bool)
+	for _, ring := range rings {
+		if (request.Kind == RoundMessage && ring.Rounds != -1) || voted[ring.ID] {
+			continue
+		}
+		voted[ring.ID] = true
+
+		vote := Vote{FractalID: request.Fractal.ID, RingID: ring.ID, Round: round, Accept: true}
+		if err := decide(ring); err != nil {
+			vote.Accept, vote.Reason = false, err.Error()
+		}
+		if follower, ok := n.Trader.Data.Strategy.(Follower); ok {
+			vote = follower.Follow(n.Trader, vote, request.Votes)
+		}
+		if !vote.Accept && reply.OK {
+			reply.OK, reply.Error = false, vote.Reason
+		}
+		reply.Votes = append(reply.Votes, vote)
+	}
+
+	if len(reply.Votes) > 0 {
+		if err := n.Trader.SignVotes(reply.Votes); err != nil {
+			n.report(err)
+			return
+		}
+	}
+	if n.Trader.Data.Params.CommitReveal {
+		commitment, err := n.Trader.commit(request.Fractal.ID, round, reply.Votes)
+		if err != nil {
+			n.report(err)
+			return
+		}
+		reply.Votes, reply.Commitment = nil, &commitment
+	}
+	n.reply(request, reply)
+}
+
+func (n *Node) reply(request Message, response Message) {
+	response.To, response.Ref = request.From, request.Ref
+	n.send(response)
+}
+
+func (n *Node) report(err error) {
+	if err != nil {
+		n.send(Message{Kind: ErrorMessage, To: SystemID, Error: err.Error()})
+	}
+}
+
+func (n *Node) send(message Message) {
+	message.From = n.Trader.ID
+	if err := n.Transport.Send(message); err != nil && message.To != SystemID {
+		n.report(err)
+	}
+}
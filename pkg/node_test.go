@@ -0,0 +1,304 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func newTestNode(t *testing.T, trader *Trader) (*Node, *LocalTransport, *[]string) {
+	t.Helper()
+	transport := NewLocalTransport()
+	errs := make([]string, 0)
+	transport.Register(SystemID, func(message Message) {
+		errs = append(errs, message.Error)
+	})
+	return NewNode(trader, transport), transport, &errs
+}
+
+func signTestCertificate(t *testing.T, voters []*Trader, team []string, fractalID, ringID string, round int, accept bool) *Certificate {
+	t.Helper()
+	votes := make([]Vote, 0, len(voters))
+	for _, member := range voters {
+		vote := []Vote{{FractalID: fractalID, RingID: ringID, Round: round, Accept: accept}}
+		if err := member.SignVotes(vote); err != nil {
+			t.Fatal(err)
+		}
+		votes = append(votes, vote[0])
+	}
+	certificate := NewCertificate(fractalID, ringID, round, team, votes, 0, nil)
+	return &certificate
+}
+
+func TestNodeIgnoresDuplicateDeliveries(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+	node, transport, errs := newTestNode(t, receiver)
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	ring := CooperationTable{ID: "ring", FractalID: "fractal", CoinIDs: []string{coin.ID}}
+	team := []string{owner.ID, receiver.ID}
+	receiver.Data.Teams[ring.FractalID] = team
+	certificate := signTestCertificate(t, traders, team, ring.FractalID, ring.ID, params.RoundsCount-1, true)
+	payout := Message{Kind: PayoutMessage, Ring: &ring, OK: true, Certificate: certificate, Payments: []Payment{{Owner: owner.ID, Coin: coin.ID, Amount: 6}}}
+	for range 2 {
+		node.Handle(Message{Kind: CoinMessage, Coin: &coin})
+		node.Handle(payout)
+	}
+	transport.Flush()
+
+	if len(*errs) != 0 {
+		t.Fatalf("expected no errors, got %v", *errs)
+	} else if status := receiver.Data.Coins[coin.ID].Status; status != Paid {
+		t.Fatalf("expected the coin to be paid, got status %d", status)
+	} else if balance := receiver.Data.Ledger.Balance(owner.ID); balance != 1001 {
+		t.Fatalf("expected the payout to be credited once, got balance %v", balance)
+	}
+}
+
+func TestNodeRejectsUncertifiedPayouts(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	if err := receiver.SaveCoin(coin); err != nil {
+		t.Fatal(err)
+	}
+	ring := CooperationTable{ID: "ring", FractalID: "fractal", CoinIDs: []string{coin.ID}}
+	team := []string{owner.ID, receiver.ID}
+	receiver.Data.Teams[ring.FractalID] = team
+	payments := []Payment{{Owner: owner.ID, Coin: coin.ID, Amount: 6}}
+
+	tests := []struct {
+		name        string
+		paid        bool
+		certificate *Certificate
+		payments    []Payment
+		err         string
+	}{
+		{"missing certificate", true, nil, payments, "missing payout certificate"},
+		{"expiry without certificate", false, nil, payments, "missing payout certificate"},
+		{"rejected ring paid", true, signTestCertificate(t, traders, team, ring.FractalID, ring.ID, params.RoundsCount-1, false), payments, "cooperation ring was not accepted"},
+		{"early round", true, signTestCertificate(t, traders, team, ring.FractalID, ring.ID, 0, true), payments, "certificate is not from the last round"},
+		{"other ring", true, signTestCertificate(t, traders, team, ring.FractalID, "other", params.RoundsCount-1, true), payments, "certificate does not match cooperation ring"},
+		{"other owner", true, signTestCertificate(t, traders, team, ring.FractalID, ring.ID, params.RoundsCount-1, true), []Payment{{Owner: receiver.ID, Coin: coin.ID, Amount: 6}}, "payment does not match cooperation ring"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, transport, errs := newTestNode(t, receiver)
+			node.Handle(Message{Kind: PayoutMessage, Ring: &ring, OK: test.paid, Certificate: test.certificate, Payments: test.payments})
+			transport.Flush()
+			if len(*errs) != 1 || (*errs)[0] != test.err {
+				t.Fatalf("expected %q, got %v", test.err, *errs)
+			} else if balance := receiver.Data.Ledger.Balance(owner.ID); balance != 995 {
+				t.Fatalf("expected no payout, got balance %v", balance)
+			}
+		})
+	}
+}
+
+func TestNodeAuthenticatesBansAndReputations(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.BanMinority = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 3)
+	sender, other, receiver := traders[0], traders[1], traders[2]
+	node, transport, errs := newTestNode(t, receiver)
+
+	ban := Message{Kind: BanMessage, From: sender.ID, To: receiver.ID, Round: 5}
+	node.Handle(ban)
+	if err := sender.SignMessage(&ban); err != nil {
+		t.Fatal(err)
+	}
+	forged := ban
+	forged.Round = 50
+	node.Handle(forged)
+	node.Handle(ban)
+	transport.Flush()
+	if receiver.Data.BanUntil != 0 {
+		t.Fatalf("expected bans from an ordinary peer to be ignored, got ban until %d", receiver.Data.BanUntil)
+	} else if len(*errs) != 3 || (*errs)[0] != "invalid message signature" || (*errs)[1] != "invalid message signature" || (*errs)[2] != "missing certificate" {
+		t.Fatalf("expected two invalid signatures and a missing certificate, got %v", *errs)
+	}
+
+	reputation := Message{Kind: ReputationMessage, To: receiver.ID, Reputations: Reputations{sender.ID: {Proposals: 100, Accepted: 100}}}
+	if err := sender.SignMessage(&reputation); err != nil {
+		t.Fatal(err)
+	}
+	node.Handle(reputation)
+	transport.Flush()
+	if len(*errs) != 4 || (*errs)[3] != "missing certificate" {
+		t.Fatalf("expected a reputation update from an ordinary peer to be rejected, got %v", *errs)
+	} else if got := receiver.Data.Traders[sender.ID].Reputation; got != (Reputation{}) {
+		t.Fatalf("expected no reputation change, got %+v", got)
+	}
+
+	team := []string{sender.ID, other.ID, receiver.ID}
+	receiver.Data.Teams["fractal"] = team
+	votes := make([]Vote, 0, len(traders))
+	for _, voter := range traders {
+		vote := []Vote{{FractalID: "fractal", RingID: "ring", Round: 0, Accept: voter != receiver}}
+		if err := voter.SignVotes(vote); err != nil {
+			t.Fatal(err)
+		}
+		votes = append(votes, vote[0])
+	}
+	certificate := NewCertificate("fractal", "ring", 0, team, votes, 0, nil)
+	for _, message := range []*Message{&ban, &reputation} {
+		message.Certificate = &certificate
+		if err := sender.SignMessage(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 2 {
+		node.Handle(ban)
+		node.Handle(reputation)
+	}
+	transport.Flush()
+	if len(*errs) != 4 {
+		t.Fatalf("expected certified decisions to be accepted, got %v", *errs)
+	} else if receiver.Data.BanUntil != params.BanCount {
+		t.Fatalf("expected a ban until %d, got %d", params.BanCount, receiver.Data.BanUntil)
+	} else if got := receiver.Data.Traders[sender.ID].Reputation; got != (Reputation{Votes: 1, Agreements: 1}) {
+		t.Fatalf("expected the update to follow from the certificate once, got %+v", got)
+	} else if got := receiver.Data.Traders[receiver.ID].Reputation; got != (Reputation{Votes: 1, Bans: 1}) {
+		t.Fatalf("expected the minority voter to be banned once, got %+v", got)
+	}
+
+	rejected := NewCertificate("invalid", "", VerificationRound, team, nil, 0, nil)
+	reputation = Message{Kind: ReputationMessage, To: receiver.ID, Certificate: &rejected, Fractal: &FractalRing{ID: "invalid", Proposer: sender.ID}}
+	if err := sender.SignMessage(&reputation); err != nil {
+		t.Fatal(err)
+	}
+	node.Handle(reputation)
+	if got := receiver.Data.Traders[sender.ID].Reputation; got != (Reputation{Votes: 1, Agreements: 1, Proposals: 1}) {
+		t.Fatalf("expected a proposer's own invalid proposal to count as rejected, got %+v", got)
+	}
+}
+
+func TestNodeSlashesUnrevealedVotesOnlyFromTheHarness(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 3)
+	offender, accuser, receiver := traders[0], traders[1], traders[2]
+	node, transport, errs := newTestNode(t, receiver)
+
+	commitment, err := offender.commit("fractal", VerificationRound, []Vote{{FractalID: "fractal", Round: VerificationRound, Accept: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fault := Fault{Type: Unrevealed, Offender: offender.ID, FractalID: "fractal", Commitment: &commitment}
+	expectError(t, receiver.VerifyFault(fault, nil), "unrevealed vote cannot be proven")
+
+	node.Handle(Message{Kind: SlashMessage, From: accuser.ID, Fault: &fault})
+	transport.Flush()
+	if len(*errs) != 1 || (*errs)[0] != "unrevealed vote cannot be proven" {
+		t.Fatalf("expected the accusation to be refused, got %v", *errs)
+	} else if balance := receiver.Data.Ledger.Balance(offender.ID); balance != 1000 {
+		t.Fatalf("expected no slash, got balance %v", balance)
+	}
+
+	node.Handle(Message{Kind: SlashMessage, From: SystemID, Fault: &fault})
+	if balance := receiver.Data.Ledger.Balance(offender.ID); balance != 1000-params.SlashNoReveal {
+		t.Fatalf("expected the harness slash to apply, got balance %v", balance)
+	}
+}
+
+func TestNodeSlashesDoubleSpendAcrossPeers(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 3)
+	owner, first, second := traders[0], traders[1], traders[2]
+
+	transport := NewLocalTransport()
+	errs := make([]string, 0)
+	transport.Register(SystemID, func(message Message) {
+		errs = append(errs, message.Error)
+	})
+	transport.Register(owner.ID, func(Message) {})
+	nodes := make(map[string]*Node)
+	for _, trader := range []*Trader{first, second} {
+		nodes[trader.ID] = NewNode(trader, transport)
+		transport.Register(trader.ID, nodes[trader.ID].Handle)
+	}
+
+	coin := createTestCoin(t, owner, 5, 0, 100)
+	owner.Sequence--
+	substitute := createTestCoin(t, owner, 9, 1, 100)
+	nodes[first.ID].Handle(Message{Kind: CoinMessage, From: owner.ID, Coin: &coin})
+	nodes[second.ID].Handle(Message{Kind: CoinMessage, From: owner.ID, Coin: &substitute})
+	transport.Flush()
+	if len(errs) != 0 {
+		t.Fatalf("expected both halves to accept their coin, got %v", errs)
+	}
+
+	nodes[second.ID].Handle(Message{Kind: CoinMessage, From: first.ID, Coin: &coin})
+	nodes[first.ID].Handle(Message{Kind: CoinMessage, From: second.ID, Coin: &substitute})
+	transport.Flush()
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	for trader, minted := range map[*Trader]float64{first: coin.Amount, second: substitute.Amount} {
+		if balance := trader.Data.Ledger.Balance(owner.ID); balance != 1000-minted-params.SlashDoubleSpend {
+			t.Fatalf("expected the owner to be slashed once in every view, got balance %v", balance)
+		} else if account := trader.Data.Traders[owner.ID].Account; account != balance {
+			t.Fatalf("expected the owner account to match the ledger, got %v", account)
+		}
+	}
+	if status := first.Data.Coins[substitute.ID].Status; status != Conflicted {
+		t.Fatalf("expected the substitute to be recorded as conflicted, got status %d", status)
+	} else if status := second.Data.Coins[coin.ID].Status; status != Conflicted {
+		t.Fatalf("expected the coin to be recorded as conflicted, got status %d", status)
+	}
+}
+
+func TestNodeRecoversDroppedCoin(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.Ledger = tools.Ed25519, true
+	traders := newTestTradersWith(t, params, 2)
+	owner, receiver := traders[0], traders[1]
+
+	transport := NewLocalTransport()
+	errs := make([]string, 0)
+	transport.Register(SystemID, func(message Message) {
+		errs = append(errs, message.Error)
+	})
+	nodes := make(map[string]*Node)
+	for _, trader := range traders {
+		nodes[trader.ID] = NewNode(trader, transport)
+		transport.Register(trader.ID, nodes[trader.ID].Handle)
+	}
+
+	coins := make([]CoinTable, 4)
+	for i := range coins {
+		coins[i] = createTestCoin(t, owner, float64(i+1), uint(i%2), 100)
+		nodes[owner.ID].Handle(Message{Kind: CoinMessage, From: owner.ID, Coin: &coins[i]})
+	}
+	nodes[receiver.ID].Handle(Message{Kind: CoinMessage, From: owner.ID, Coin: &coins[1]})
+	transport.Flush()
+	if len(receiver.Data.pending) != 1 {
+		t.Fatalf("expected one buffered coin before asking for a resend, got %d", len(receiver.Data.pending))
+	}
+	for _, coin := range coins[2:] {
+		nodes[receiver.ID].Handle(Message{Kind: CoinMessage, From: owner.ID, Coin: &coin})
+	}
+	transport.Flush()
+
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	} else if len(receiver.Data.pending) != 0 {
+		t.Fatalf("expected the gap to be filled, got %d buffered coins", len(receiver.Data.pending))
+	} else if sequence := receiver.Data.Traders[owner.ID].Sequence; sequence != owner.Data.Traders[owner.ID].Sequence {
+		t.Fatalf("expected owner sequence %d, got %d", owner.Data.Traders[owner.ID].Sequence, sequence)
+	} else if balance := receiver.Data.Ledger.Balance(owner.ID); balance != owner.Data.Ledger.Balance(owner.ID) {
+		t.Fatalf("expected the views to agree on the owner balance, got %v and %v", balance, owner.Data.Ledger.Balance(owner.ID))
+	}
+	for _, coin := range coins {
+		if _, ok := receiver.Data.Coins[coin.ID]; !ok {
+			t.Fatalf("expected coin %d to be saved", coin.Sequence)
+		}
+	}
+}
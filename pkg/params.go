@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type VotingRule string
+
+const (
+	CountVotes   VotingRule = "count"
+	AccountVotes VotingRule = "account"
+	StakeVotes   VotingRule = "stake"
+)
+
+const (
+	MajorityQuorum = 0.5
+	BFTQuorum      = 2. / 3
+)
+
+type Params struct {
+	FractalMin           int                   `json:"fractal_min"`
+	FractalMax           int                   `json:"fractal_max"`
+	FractalPrize         float64               `json:"fractal_prize"`
+	RoundsCount          int                   `json:"rounds_count"`
+	RoundLength          int64                 `json:"round_length"`
+	VerificationMin      int                   `json:"verification_min"`
+	VerificationMax      int                   `json:"verification_max"`
+	BanCount             int                   `json:"ban_count"`
+	BanMinority          bool                  `json:"ban_minority"`
+	SlashEquivocation    float64               `json:"slash_equivocation"`
+	SlashInvalidProposal float64               `json:"slash_invalid_proposal"`
+	SlashInvalidApproval float64               `json:"slash_invalid_approval"`
+	SignatureScheme      tools.SignatureScheme `json:"signature_scheme"`
+	KeySize              int                   `json:"key_size"`
+	IDEncoding           tools.IDEncoding      `json:"id_encoding"`
+	BadBehavior          float64               `json:"bad_behavior"`
+	ReputationTeams      bool                  `json:"reputation_teams"`
+	VotingRule           VotingRule            `json:"voting_rule"`
+	Quorum               float64               `json:"quorum"`
+	CommitReveal         bool                  `json:"commit_reveal"`
+	SlashNoReveal        float64               `json:"slash_no_reveal"`
+	SlashDoubleSpend     float64               `json:"slash_double_spend"`
+	CoinTTL              int64                 `json:"coin_ttl"`
+	ResendAfter          int                   `json:"resend_after"`
+	Ledger               bool                  `json:"ledger"`
+	CheckInvariants      bool                  `json:"check_invariants"`
+	Debug                bool                  `json:"debug"`
+	RunFractals          bool                  `json:"run_fractals"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		FractalMin:           50,
+		FractalMax:           200,
+		FractalPrize:         5,
+		RoundsCount:          10,
+		RoundLength:          1000,
+		VerificationMin:      21,
+		VerificationMax:      21,
+		BanCount:             3,
+		SlashEquivocation:    50,
+		SlashInvalidProposal: 20,
+		SlashInvalidApproval: 10,
+		SlashNoReveal:        10,
+		SlashDoubleSpend:     50,
+		ResendAfter:          2,
+		SignatureScheme:      tools.RSAPSS,
+		KeySize:              2048,
+		IDEncoding:           tools.HexIDs,
+		VotingRule:           CountVotes,
+		Quorum:               MajorityQuorum,
+		BadBehavior:          0.1,
+		Debug:                false,
+		RunFractals:          true,
+	}
+}
+
+func LoadParams(filePath string) (Params, error) {
+	params := DefaultParams()
+	file, err := os.Open(filePath)
+	if err != nil {
+		return params, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&params); err != nil {
+		return params, err
+	}
+	return params, params.Validate()
+}
+
+func (p Params) Validate() error {
+	if p.FractalMin < 1 || p.FractalMax < p.FractalMin {
+		return errors.New("invalid fractal ring size range")
+	} else if p.VerificationMin < 1 || p.VerificationMax < p.VerificationMin {
+		return errors.New("invalid verification team size range")
+	} else if p.RoundsCount < 1 || p.RoundLength < 1 {
+		return errors.New("rounds count and round length must be positive")
+	} else if p.FractalPrize < 0 || p.BanCount < 0 {
+		return errors.New("fractal prize and ban count must be non-negative")
+	} else if p.CoinTTL < 0 || p.ResendAfter < 0 {
+		return errors.New("coin ttl and resend after must be non-negative")
+	} else if p.SlashEquivocation < 0 || p.SlashInvalidProposal < 0 || p.SlashInvalidApproval < 0 || p.SlashNoReveal < 0 || p.SlashDoubleSpend < 0 {
+		return errors.New("slashing amounts must be non-negative")
+	} else if p.VotingRule != CountVotes && p.VotingRule != AccountVotes && p.VotingRule != StakeVotes {
+		return errors.New("voting rule must be count, account or stake")
+	} else if p.Quorum < MajorityQuorum || p.Quorum > 1 {
+		return errors.New("quorum must be between 0.5 and 1")
+	} else if p.SignatureScheme != tools.RSAPSS && p.SignatureScheme != tools.Ed25519 {
+		return errors.New("signature scheme must be rsa-pss or ed25519")
+	} else if p.SignatureScheme == tools.RSAPSS && p.KeySize < 1024 {
+		return errors.New("key size must be at least 1024 bits")
+	} else if p.IDEncoding != tools.HexIDs && p.IDEncoding != tools.Base64IDs {
+		return errors.New("id encoding must be hex or base64")
+	} else if p.BadBehavior < 0 || p.BadBehavior > 1 {
+		return errors.New("bad behavior percentage must be between 0 and 1")
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"errors"
+	"slices"
+)
+
+type Reputation struct {
+	Votes      int `json:"votes"`
+	Agreements int `json:"agreements"`
+	Proposals  int `json:"proposals"`
+	Accepted   int `json:"accepted"`
+	Bans       int `json:"bans"`
+}
+
+type Reputations map[string]Reputation
+
+func (r Reputation) Add(other Reputation) Reputation {
+	return Reputation{
+		Votes:      r.Votes + other.Votes,
+		Agreements: r.Agreements + other.Agreements,
+		Proposals:  r.Proposals + other.Proposals,
+		Accepted:   r.Accepted + other.Accepted,
+		Bans:       r.Bans + other.Bans,
+	}
+}
+
+func (r Reputation) Score() float64 {
+	agreement := float64(r.Agreements+1) / float64(r.Votes+2)
+	acceptance := float64(r.Accepted+1) / float64(r.Proposals+2)
+	return agreement * acceptance / float64(1+r.Bans)
+}
+
+func (r Reputations) AddVotes(certificate Certificate) {
+	for _, vote := range certificate.Votes {
+		reputation := r[vote.Voter]
+		reputation.Votes++
+		if vote.Accept == certificate.Accepted {
+			reputation.Agreements++
+		}
+		r[vote.Voter] = reputation
+	}
+}
+
+func (r Reputations) AddBans(traderIDs []string) {
+	for _, traderID := range traderIDs {
+		reputation := r[traderID]
+		reputation.Bans++
+		r[traderID] = reputation
+	}
+}
+
+func (r Reputations) AddProposal(traderID string, accepted bool) {
+	reputation := r[traderID]
+	reputation.Proposals++
+	if accepted {
+		reputation.Accepted++
+	}
+	r[traderID] = reputation
+}
+
+func (r Reputations) Merge(updates Reputations) {
+	for traderID, update := range updates {
+		r[traderID] = r[traderID].Add(update)
+	}
+}
+
+func TeamWeights(params *Params, traders []string, reputation func(traderID string) Reputation) map[string]float64 {
+	if !params.ReputationTeams {
+		return nil
+	}
+	weights := make(map[string]float64, len(traders))
+	for _, traderID := range traders {
+		weights[traderID] = reputation(traderID).Score()
+	}
+	return weights
+}
+
+func (t *Trader) teamWeights(traders []string) map[string]float64 {
+	return TeamWeights(t.Data.Params, traders, func(traderID string) Reputation {
+		return t.Data.Traders[traderID].Reputation
+	})
+}
+
+func (t *Trader) UpdateReputations(updates Reputations) {
+	for traderID, update := range updates {
+		if trader, ok := t.Data.Traders[traderID]; ok {
+			trader.Reputation = trader.Reputation.Add(update)
+			t.Data.Traders[traderID] = trader
+		}
+		if traderID == t.ID {
+			t.Reputation = t.Reputation.Add(update)
+		}
+	}
+}
+
+func (t *Trader) decide(kind string, certificate *Certificate) bool {
+	if t.Data.decided == nil {
+		t.Data.decided = make(map[string]bool)
+	}
+	key := kind + "-" + certificate.subject()
+	if t.Data.decided[key] {
+		return false
+	}
+	t.Data.decided[key] = true
+	return true
+}
+
+func (t *Trader) certifiedFractals() (count int) {
+	for _, trader := range t.Data.Traders {
+		count += trader.Certified
+	}
+	return
+}
+
+func (t *Trader) verifyBan(message Message) (int, error) {
+	if err := t.verifySender(message); err != nil {
+		return 0, err
+	} else if message.From == SystemID {
+		return message.Round, nil
+	} else if !t.Data.Params.BanMinority {
+		return 0, errors.New("minority bans are disabled")
+	} else if err := t.verifyDecision(message.Certificate, message.Fractal); err != nil {
+		return 0, err
+	} else if !slices.Contains(message.Certificate.Minority(), t.ID) {
+		return 0, errors.New("trader is not in the minority")
+	} else if !t.decide("ban", message.Certificate) {
+		return t.Data.BanUntil, nil
+	}
+	return t.certifiedFractals() + t.Data.Params.BanCount, nil
+}
+
+func (t *Trader) verifyReputations(message Message) (Reputations, error) {
+	if err := t.verifySender(message); err != nil {
+		return nil, err
+	} else if message.From == SystemID {
+		return message.Reputations, nil
+	}
+
+	certificate, fractal := message.Certificate, message.Fractal
+	updates := make(Reputations)
+	if certificate != nil && certificate.Round == VerificationRound && fractal != nil && fractal.ID == certificate.FractalID && fractal.Proposer == message.From && t.checkFractalRing(fractal) != nil {
+		if t.decide("reputation", certificate) {
+			updates.AddProposal(fractal.Proposer, false)
+		}
+		return updates, nil
+	} else if err := t.verifyDecision(certificate, fractal); err != nil {
+		return nil, err
+	} else if !t.decide("reputation", certificate) {
+		return nil, nil
+	}
+	updates.AddVotes(*certificate)
+	if certificate.Round == VerificationRound {
+		updates.AddProposal(fractal.Proposer, certificate.Accepted)
+	}
+	if t.Data.Params.BanMinority {
+		updates.AddBans(certificate.Minority())
+	}
+	return updates, nil
+}
@@ -0,0 +1,197 @@
+package pkg
+
+import (
+	"errors"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type TraderState struct {
+	TraderType    BehaviorType                `json:"trader_type"`
+	CoinTypeCount uint                        `json:"coin_type_count"`
+	Random        []byte                      `json:"random"`
+	PrivateKey    []byte                      `json:"private_key"`
+	Traders       map[string]Trader           `json:"traders"`
+	Coins         map[string]CoinTable        `json:"coins"`
+	Cooperations  map[string]CooperationTable `json:"cooperations"`
+	UnusedCoins   map[string][][]string       `json:"unused_coins"`
+	Teams         map[string][]string         `json:"teams"`
+	Weights       map[string][]float64        `json:"weights,omitempty"`
+	BanUntil      int                         `json:"ban_until"`
+	Conflicts     int                         `json:"conflicts"`
+	Ledger        Ledger                      `json:"ledger,omitempty"`
+	Pending       map[string]CoinTable        `json:"pending,omitempty"`
+	Commitments   map[string]committed        `json:"commitments,omitempty"`
+	Decided       map[string]bool             `json:"decided,omitempty"`
+	Draw          *selectionDraw              `json:"draw,omitempty"`
+}
+
+func (t *Trader) State() (*TraderState, error) {
+	if t.Data == nil {
+		return nil, errors.New("trader has no data")
+	}
+
+	random, err := t.Data.Random.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := t.Data.PrivateKey.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	unusedCoins := make(map[string][][]string)
+	for cooperationID, cooperation := range t.Data.Cooperations {
+		if cooperation.UnusedCoins != nil {
+			unusedCoins[cooperationID] = cooperation.UnusedCoins
+		}
+	}
+
+	return &TraderState{
+		TraderType:    t.Data.TraderType,
+		CoinTypeCount: t.Data.CoinTypeCount,
+		Random:        random,
+		PrivateKey:    privateKey,
+		Traders:       t.Data.Traders,
+		Coins:         t.Data.Coins,
+		Cooperations:  t.Data.Cooperations,
+		UnusedCoins:   unusedCoins,
+		Teams:         t.Data.Teams,
+		Weights:       t.Data.Weights,
+		BanUntil:      t.Data.BanUntil,
+		Conflicts:     t.Data.Conflicts,
+		Ledger:        t.Data.Ledger,
+		Pending:       t.Data.pending,
+		Commitments:   t.Data.commitments,
+		Decided:       t.Data.decided,
+		Draw:          t.Data.draw,
+	}, nil
+}
+
+func (t *Trader) Restore(params *Params, state *TraderState) error {
+	strategy, err := NewStrategy(state.TraderType, params)
+	if err != nil {
+		return err
+	}
+
+	random := tools.NewRandom(0)
+	if err := random.UnmarshalBinary(state.Random); err != nil {
+		return err
+	}
+	privateKey, err := tools.ParsePrivateKey(state.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if !privateKey.Public().Equal(t.PublicKey) {
+		return errors.New("private key does not match trader")
+	}
+
+	if state.Traders == nil {
+		state.Traders = make(map[string]Trader)
+	}
+	if state.Coins == nil {
+		state.Coins = make(map[string]CoinTable)
+	}
+	if state.Cooperations == nil {
+		state.Cooperations = make(map[string]CooperationTable)
+	}
+	if state.Teams == nil {
+		state.Teams = make(map[string][]string)
+	}
+	if state.Weights == nil {
+		state.Weights = make(map[string][]float64)
+	}
+	for cooperationID, unusedCoins := range state.UnusedCoins {
+		if cooperation, ok := state.Cooperations[cooperationID]; ok {
+			cooperation.UnusedCoins = unusedCoins
+			state.Cooperations[cooperationID] = cooperation
+		}
+	}
+
+	t.Data = &TraderData{
+		Params:        params,
+		Random:        random,
+		TraderType:    state.TraderType,
+		Strategy:      strategy,
+		PrivateKey:    privateKey,
+		CoinTypeCount: state.CoinTypeCount,
+		Traders:       state.Traders,
+		Coins:         state.Coins,
+		Cooperations:  state.Cooperations,
+		Teams:         state.Teams,
+		Weights:       state.Weights,
+		BanUntil:      state.BanUntil,
+		Conflicts:     state.Conflicts,
+		Ledger:        state.Ledger,
+		pending:       state.Pending,
+		commitments:   state.Commitments,
+		decided:       state.Decided,
+		draw:          state.Draw,
+	}
+	t.SetLedger(params.Ledger)
+	return nil
+}
+
+func (state TraderState) CoinIDs() []string {
+	var coinIDs []string
+	for coinID, coin := range state.Coins {
+		coinIDs = append(coinIDs, coinID, coin.Next, coin.Prev)
+	}
+	for coinID := range state.Pending {
+		coinIDs = append(coinIDs, coinID)
+	}
+	for _, cooperation := range state.Cooperations {
+		coinIDs = append(coinIDs, cooperation.Investor)
+		coinIDs = append(coinIDs, cooperation.CoinIDs...)
+	}
+	for _, unusedCoins := range state.UnusedCoins {
+		for _, coins := range unusedCoins {
+			coinIDs = append(coinIDs, coins...)
+		}
+	}
+	return coinIDs
+}
+
+func (state TraderState) MapCoinIDs(mapping func(string) string) TraderState {
+	mapAll := func(coinIDs []string) []string {
+		if coinIDs == nil {
+			return nil
+		}
+		result := make([]string, len(coinIDs))
+		for i, coinID := range coinIDs {
+			result[i] = mapping(coinID)
+		}
+		return result
+	}
+
+	coins := make(map[string]CoinTable, len(state.Coins))
+	for coinID, coin := range state.Coins {
+		coin.ID, coin.Next, coin.Prev = mapping(coin.ID), mapping(coin.Next), mapping(coin.Prev)
+		coins[mapping(coinID)] = coin
+	}
+	var pending map[string]CoinTable
+	if state.Pending != nil {
+		pending = make(map[string]CoinTable, len(state.Pending))
+	}
+	for coinID, coin := range state.Pending {
+		coin.ID = mapping(coin.ID)
+		pending[mapping(coinID)] = coin
+	}
+	cooperations := make(map[string]CooperationTable, len(state.Cooperations))
+	for cooperationID, cooperation := range state.Cooperations {
+		cooperation.Investor, cooperation.CoinIDs = mapping(cooperation.Investor), mapAll(cooperation.CoinIDs)
+		cooperation.UnusedCoins = nil
+		cooperations[cooperationID] = cooperation
+	}
+	unusedCoins := make(map[string][][]string, len(state.UnusedCoins))
+	for cooperationID, coinIDs := range state.UnusedCoins {
+		mapped := make([][]string, len(coinIDs))
+		for i, coins := range coinIDs {
+			mapped[i] = mapAll(coins)
+		}
+		unusedCoins[cooperationID] = mapped
+	}
+
+	state.Coins, state.Cooperations, state.UnusedCoins, state.Pending = coins, cooperations, unusedCoins, pending
+	return state
+}
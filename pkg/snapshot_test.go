@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func TestTraderStateRoundTripKeepsPendingCoins(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 2)
+	owner, receiver := traders[0], traders[1]
+
+	missing := createTestCoin(t, owner, 5, 0, 100)
+	buffered := createTestCoin(t, owner, 3, 1, 100)
+	if err := receiver.SaveCoin(buffered); err != nil {
+		t.Fatal(err)
+	}
+	votes := []Vote{{Voter: receiver.ID, FractalID: "fractal", Round: 1, Accept: true}}
+	if _, err := receiver.commit("fractal", 1, votes); err != nil {
+		t.Fatal(err)
+	}
+	output, _, err := receiver.drawSelection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver.decide("reputation", &Certificate{FractalID: "fractal", Round: 1})
+
+	state, err := receiver.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	interned := state.MapCoinIDs(func(coinID string) string { return "#" + coinID })
+	data, err := json.Marshal(interned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded TraderState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	loaded = loaded.MapCoinIDs(func(coinID string) string { return coinID[1:] })
+
+	restored := *receiver
+	if err := restored.Restore(receiver.Data.Params, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restored.Data.pending[buffered.ID]; !ok {
+		t.Fatal("expected the buffered coin to survive the round trip")
+	} else if draw := restored.Data.draw; draw == nil || draw.Output != output {
+		t.Fatalf("expected the cached selection draw %s, got %v", output, draw)
+	} else if restored.decide("reputation", &Certificate{FractalID: "fractal", Round: 1}) {
+		t.Fatal("expected the decided certificate to survive the round trip")
+	} else if revealed, _, ok := restored.reveal("fractal", 1, nil); !ok || len(revealed) != 1 || revealed[0].Voter != receiver.ID {
+		t.Fatalf("expected the committed votes to survive the round trip, got %v", revealed)
+	}
+
+	if err := restored.SaveCoin(missing); err != nil {
+		t.Fatal(err)
+	} else if _, ok := restored.Data.Coins[buffered.ID]; !ok {
+		t.Fatal("expected the buffered coin to be saved once the gap is filled")
+	} else if restored.Data.Traders[owner.ID].Sequence != buffered.Sequence {
+		t.Fatalf("expected owner sequence %d, got %d", buffered.Sequence, restored.Data.Traders[owner.ID].Sequence)
+	}
+}
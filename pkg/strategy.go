@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"errors"
+	"slices"
+)
+
+type Strategy interface {
+	ProposeFractal(t *Trader, soloRings []string) (ring []string, isValid bool)
+	SelectTeam(t *Trader, traders []string, ring []string) (team []string, isValid bool)
+	VerifyVote(t *Trader, fractal *FractalRing, err error) error
+	RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error
+}
+
+var strategies = map[BehaviorType]func(params *Params) Strategy{
+	Normal:     func(params *Params) Strategy { return NormalStrategy{} },
+	RandomVote: func(params *Params) Strategy { return RandomVoteStrategy{Alpha: params.BadBehavior} },
+	BadVote:    func(params *Params) Strategy { return BadVoteStrategy{} },
+	Colluder:   func(params *Params) Strategy { return CoalitionStrategy{Coalition: NewCoalition()} },
+	Sybil:      func(params *Params) Strategy { return CoalitionStrategy{Coalition: NewCoalition()} },
+	Copycat:    func(params *Params) Strategy { return CopycatStrategy{} },
+}
+
+type Follower interface {
+	Follow(t *Trader, vote Vote, observed []Vote) Vote
+	Reveal(t *Trader, votes []Vote, observed []Vote) bool
+}
+
+func RegisterStrategy(behavior BehaviorType, factory func(params *Params) Strategy) {
+	strategies[behavior] = factory
+}
+
+func NewStrategy(behavior BehaviorType, params *Params) (Strategy, error) {
+	factory, ok := strategies[behavior]
+	if !ok {
+		return nil, errors.New("unknown behavior type")
+	}
+	return factory(params), nil
+}
+
+type NormalStrategy struct{}
+
+func (NormalStrategy) ProposeFractal(t *Trader, soloRings []string) ([]string, bool) {
+	return proposeFractal(t, soloRings, false)
+}
+
+func (NormalStrategy) SelectTeam(t *Trader, traders []string, ring []string) ([]string, bool) {
+	return selectTeam(t, traders, ring, false)
+}
+
+func (NormalStrategy) VerifyVote(t *Trader, fractal *FractalRing, err error) error {
+	return verifyVote(err, false)
+}
+
+func (NormalStrategy) RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error {
+	return roundVote(false)
+}
+
+type RandomVoteStrategy struct {
+	Alpha float64
+}
+
+func (s RandomVoteStrategy) misbehave(t *Trader) bool {
+	return t.Data.Random.Float64() < s.Alpha
+}
+
+func (s RandomVoteStrategy) ProposeFractal(t *Trader, soloRings []string) ([]string, bool) {
+	return proposeFractal(t, soloRings, s.misbehave(t))
+}
+
+func (s RandomVoteStrategy) SelectTeam(t *Trader, traders []string, ring []string) ([]string, bool) {
+	return selectTeam(t, traders, ring, s.misbehave(t))
+}
+
+func (s RandomVoteStrategy) VerifyVote(t *Trader, fractal *FractalRing, err error) error {
+	return verifyVote(err, s.misbehave(t))
+}
+
+func (s RandomVoteStrategy) RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error {
+	return roundVote(s.misbehave(t))
+}
+
+type BadVoteStrategy struct{}
+
+func (BadVoteStrategy) ProposeFractal(t *Trader, soloRings []string) ([]string, bool) {
+	return proposeFractal(t, soloRings, true)
+}
+
+func (BadVoteStrategy) SelectTeam(t *Trader, traders []string, ring []string) ([]string, bool) {
+	return selectTeam(t, traders, ring, true)
+}
+
+func (BadVoteStrategy) VerifyVote(t *Trader, fractal *FractalRing, err error) error {
+	return verifyVote(err, true)
+}
+
+func (BadVoteStrategy) RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error {
+	return roundVote(true)
+}
+
+type CopycatStrategy struct {
+	NormalStrategy
+}
+
+func (CopycatStrategy) VerifyVote(t *Trader, fractal *FractalRing, err error) error {
+	return roundVote(t.Data.Random.Float64() < 0.5)
+}
+
+func (CopycatStrategy) RoundVote(t *Trader, fractal *FractalRing, ring CooperationTable) error {
+	return roundVote(t.Data.Random.Float64() < 0.5)
+}
+
+func (CopycatStrategy) Follow(t *Trader, vote Vote, observed []Vote) Vote {
+	if majority, ok := majorityVote(vote, observed); ok {
+		vote.Accept, vote.Reason = majority.Accept, majority.Reason
+	}
+	return vote
+}
+
+func (CopycatStrategy) Reveal(t *Trader, votes []Vote, observed []Vote) bool {
+	for _, vote := range votes {
+		if majority, ok := majorityVote(vote, observed); ok && majority.Accept != vote.Accept {
+			return false
+		}
+	}
+	return true
+}
+
+func majorityVote(vote Vote, observed []Vote) (Vote, bool) {
+	var accepted, rejected []Vote
+	for _, other := range observed {
+		if other.FractalID != vote.FractalID || other.RingID != vote.RingID || other.Round != vote.Round {
+			continue
+		} else if other.Accept {
+			accepted = append(accepted, other)
+		} else {
+			rejected = append(rejected, other)
+		}
+	}
+
+	if len(accepted) == 0 && len(rejected) == 0 {
+		return vote, false
+	} else if len(rejected) > len(accepted) {
+		return rejected[0], true
+	}
+	return accepted[0], true
+}
+
+func proposeFractal(t *Trader, soloRings []string, misbehave bool) ([]string, bool) {
+	if misbehave {
+		return selectRandomFractal(t.Data.Params, t.Data.Random, soloRings), false
+	}
+	ringSeed, _ := t.selectionSeeds()
+	return selectFractalRing(t.Data.Params, soloRings, firstPick(soloRings, ringSeed)), true
+}
+
+func selectTeam(t *Trader, traders []string, ring []string, misbehave bool) ([]string, bool) {
+	if misbehave {
+		return selectRandomVerification(t.Data.Params, t.Data.Random, traders), false
+	}
+	_, teamSeed := t.selectionSeeds()
+	return selectVerificationTeam(t.Data.Params, traders, ring, firstPick(traders, teamSeed), t.teamWeights(traders)), true
+}
+
+func verifyVote(err error, misbehave bool) error {
+	if err != nil {
+		if misbehave && IsDisputableError(err) {
+			return nil
+		}
+		return err
+	}
+	return roundVote(misbehave)
+}
+
+func roundVote(misbehave bool) error {
+	if misbehave {
+		return errors.New("bad behavior")
+	}
+	return nil
+}
+
+func IsDisputableError(err error) bool {
+	validErrors := []string{"invalid selected cooperation ring", "invalid verification team", "invalid cooperation ring coins", "invalid proposal counter", "invalid vrf proof"}
+	return slices.Contains(validErrors, err.Error())
+}
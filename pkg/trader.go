@@ -1,75 +1,114 @@
 package pkg
 
 import (
-	"crypto/rsa"
 	"errors"
+	"io"
+	"maps"
+	"slices"
 	"strconv"
-	"time"
 
 	"github.com/Arka-Lab/LoR/tools"
 )
 
-const (
-	KeySize = 2048
-)
-
-var (
-	BadBehavior = 0.1
-)
-
 type BehaviorType int
 
 const (
 	Normal BehaviorType = iota
 	RandomVote
 	BadVote
+	Colluder
+	Sybil
+	Copycat
 )
 
+func (b BehaviorType) String() string {
+	switch b {
+	case Normal:
+		return "normal"
+	case RandomVote:
+		return "random"
+	case BadVote:
+		return "bad"
+	case Colluder:
+		return "colluder"
+	case Sybil:
+		return "sybil"
+	case Copycat:
+		return "copycat"
+	}
+	return "unknown"
+}
+
 type TraderData struct {
+	Params        *Params
 	TraderType    BehaviorType
+	Strategy      Strategy
 	CoinTypeCount uint
-	Ticker        *time.Ticker
-	PrivateKey    *rsa.PrivateKey
+	Random        *tools.Random
+	PrivateKey    *tools.PrivateKey
 	Traders       map[string]Trader
 	Coins         map[string]CoinTable
 	Cooperations  map[string]CooperationTable
+	Teams         map[string][]string
+	Weights       map[string][]float64
 	BanUntil      int
+	Conflicts     int
+	Ledger        Ledger
+
+	commitments map[string]committed
+	pending     map[string]CoinTable
+	sequences   map[string]map[uint64]string
+	decided     map[string]bool
+	verified    map[string]map[string]bool
+	draw        *selectionDraw
 }
 
 type Trader struct {
-	ID        string         `json:"id"`
-	Account   float64        `json:"account"`
-	Wallet    string         `json:"wallet"`
-	PublicKey *rsa.PublicKey `json:"public_key"`
+	ID         string           `json:"id"`
+	Account    float64          `json:"account"`
+	Wallet     string           `json:"wallet"`
+	PublicKey  *tools.PublicKey `json:"public_key"`
+	Reputation Reputation       `json:"reputation"`
+	Sequence   uint64           `json:"sequence"`
+	Certified  int              `json:"certified"`
 
 	Data *TraderData `json:"-"`
 }
 
-func CreateTrader(traderType BehaviorType, account float64, wallet string, coinTypeCount uint) *Trader {
-	privateKey, err := tools.GeneratePrivateKey(KeySize)
+func CreateTrader(params *Params, traderType BehaviorType, account float64, wallet string, coinTypeCount uint, random *tools.Random, keyRandom io.Reader) *Trader {
+	strategy, err := NewStrategy(traderType, params)
+	if err != nil {
+		return nil
+	}
+	privateKey, err := tools.GeneratePrivateKey(keyRandom, params.SignatureScheme, params.KeySize)
 	if err != nil {
 		return nil
 	}
 
-	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
-	ticker := time.NewTicker(RoundLength * time.Millisecond)
-
-	return &Trader{
+	trader := &Trader{
 		ID:        tools.SHA256Str(wallet + "-" + strconv.Itoa(int(coinTypeCount))),
 		Account:   account,
 		Wallet:    wallet,
-		PublicKey: &privateKey.PublicKey,
+		PublicKey: privateKey.Public(),
 		Data: &TraderData{
-			Ticker:        ticker,
+			Params:        params,
+			Random:        random,
 			TraderType:    traderType,
+			Strategy:      strategy,
 			PrivateKey:    privateKey,
 			CoinTypeCount: coinTypeCount,
 			Traders:       make(map[string]Trader),
 			Coins:         make(map[string]CoinTable),
 			Cooperations:  make(map[string]CooperationTable),
+			Teams:         make(map[string][]string),
+			Weights:       make(map[string][]float64),
 			BanUntil:      0,
 		},
 	}
+	if params.Ledger {
+		trader.Data.Ledger = make(Ledger)
+	}
+	return trader
 }
 
 func (t *Trader) SaveTrader(trader Trader) error {
@@ -81,9 +120,103 @@ func (t *Trader) SaveTrader(trader Trader) error {
 	}
 
 	t.Data.Traders[trader.ID] = trader
+	if t.Data.Ledger != nil {
+		t.Data.Ledger.Append(trader.ID, OpenEntry, trader.Account, "")
+	}
 	return nil
 }
 
+func (t *Trader) RemoveTrader(traderID string) error {
+	if _, ok := t.Data.Traders[traderID]; !ok {
+		return errors.New("trader not found")
+	}
+
+	for _, coinID := range slices.Sorted(maps.Keys(t.Data.Coins)) {
+		coin := t.Data.Coins[coinID]
+		if coin.Owner != traderID || coin.Status != Run {
+			continue
+		}
+		t.leaveRing(coin)
+		t.post(traderID, RefundEntry, coin.Amount, coinID)
+		delete(t.Data.Coins, coinID)
+		t.unindexCoin(coin)
+	}
+	delete(t.Data.Traders, traderID)
+	return nil
+}
+
+func (t *Trader) leaveRing(coin CoinTable) {
+	if coin.CooperationID == "" {
+		return
+	}
+	if ring, ok := t.Data.Cooperations[coin.CooperationID]; ok && ring.FractalID != "" {
+		t.RemoveFractalRing(ring.FractalID)
+	} else if ok {
+		t.removeCooperatinRing(ring.ID)
+	}
+}
+
+func (t *Trader) View() View {
+	view := View{
+		Traders:      make(map[string]Trader),
+		Coins:        make(map[string]CoinTable),
+		Cooperations: make(map[string]CooperationTable),
+		Teams:        make(map[string][]string),
+		Weights:      make(map[string][]float64),
+	}
+	for traderID, trader := range t.Data.Traders {
+		view.Traders[traderID] = trader
+	}
+	for fractalID, team := range t.Data.Teams {
+		view.Teams[fractalID] = team
+	}
+	for fractalID, weights := range t.Data.Weights {
+		view.Weights[fractalID] = weights
+	}
+	for cooperationID, cooperation := range t.Data.Cooperations {
+		if cooperation.FractalID != "" {
+			view.Cooperations[cooperationID] = cooperation
+		}
+	}
+	for coinID, coin := range t.Data.Coins {
+		if coin.Status == Run {
+			coin.Next, coin.Prev, coin.CooperationID = "", "", ""
+		}
+		view.Coins[coinID] = coin
+	}
+	if t.Data.Ledger != nil {
+		view.Ledger = t.Data.Ledger.Clone()
+	}
+	return view
+}
+
+func (t *Trader) ApplyView(view View) {
+	for traderID, trader := range view.Traders {
+		t.Data.Traders[traderID] = trader
+	}
+	for cooperationID, cooperation := range view.Cooperations {
+		t.Data.Cooperations[cooperationID] = cooperation
+	}
+	for fractalID, team := range view.Teams {
+		t.Data.Teams[fractalID] = team
+	}
+	for fractalID, weights := range view.Weights {
+		t.Data.Weights[fractalID] = weights
+	}
+	for coinID, coin := range view.Coins {
+		t.Data.Coins[coinID] = coin
+	}
+	t.Data.sequences = nil
+	if t.Data.Ledger != nil {
+		for account, entries := range view.Ledger {
+			t.Data.Ledger[account] = slices.Clip(entries)
+		}
+	}
+	for traderID := range view.Traders {
+		t.savePendingCoins(traderID)
+	}
+}
+
 func (t *Trader) CheckForRings(fractalCounter int) *FractalRing {
 	if cooperation := t.checkForCooperationRing(); cooperation != nil {
 		t.Data.Cooperations[cooperation.ID] = *cooperation
@@ -95,6 +228,10 @@ func (t *Trader) CheckForRings(fractalCounter int) *FractalRing {
 }
 
 func (t *Trader) InformFractalRing(fractal FractalRing) error {
+	weights, err := t.verifyAcceptance(fractal)
+	if err != nil {
+		return err
+	}
 	for _, cooperation := range fractal.CooperationRings {
 		for _, coinID := range cooperation.CoinIDs {
 			if coin, ok := t.Data.Coins[coinID]; !ok {
@@ -102,6 +239,7 @@ func (t *Trader) InformFractalRing(fractal FractalRing) error {
 			} else if coin.Status != Run {
 				return errors.New("coin is not running")
 			} else if coin.CooperationID != "" && coin.CooperationID != cooperation.ID {
+				t.Data.Conflicts++
 				if ring, ok := t.Data.Cooperations[coin.CooperationID]; !ok {
 					return errors.New("cooperating not found")
 				} else if ring.FractalID != "" {
@@ -114,6 +252,17 @@ func (t *Trader) InformFractalRing(fractal FractalRing) error {
 	}
 
 	t.saveFractalRing(fractal)
+	t.Data.Teams[fractal.ID] = fractal.VerificationTeam
+	if proposer, ok := t.Data.Traders[fractal.Proposer]; ok {
+		proposer.Certified++
+		t.Data.Traders[fractal.Proposer] = proposer
+	}
+	if fractal.Proposer == t.ID {
+		t.Certified++
+	}
+	if weights != nil {
+		t.Data.Weights[fractal.ID] = weights
+	}
 	return nil
 }
 
@@ -133,6 +282,9 @@ func (t *Trader) saveFractalRing(fractal FractalRing) {
 }
 
 func (t *Trader) RemoveFractalRing(fractalID string) {
+	delete(t.Data.Teams, fractalID)
+	delete(t.Data.Weights, fractalID)
+	delete(t.Data.verified, fractalID)
 	for _, cooperation := range t.Data.Cooperations {
 		if cooperation.FractalID == fractalID {
 			t.removeCooperatinRing(cooperation.ID)
@@ -143,6 +295,9 @@ func (t *Trader) RemoveFractalRing(fractalID string) {
 func (t *Trader) removeCooperatinRing(cooperationID string) {
 	for _, coinID := range t.Data.Cooperations[cooperationID].CoinIDs {
 		coin := t.Data.Coins[coinID]
+		if coin.Status == Refunded {
+			continue
+		}
 		coin.Prev = ""
 		coin.Next = ""
 		coin.Status = Run
@@ -152,14 +307,19 @@ func (t *Trader) removeCooperatinRing(cooperationID string) {
 	delete(t.Data.Cooperations, cooperationID)
 }
 
-func (t *Trader) UpdateBalance(traderID string, amount float64) error {
+func (t *Trader) UpdateBalance(traderID string, kind EntryKind, amount float64, ref string) error {
 	if trader, ok := t.Data.Traders[traderID]; !ok {
 		return errors.New("trader not found")
 	} else if trader.Account+amount < 0 {
 		return errors.New("insufficient account")
-	} else {
-		trader.Account += amount
-		t.Data.Traders[traderID] = trader
 	}
+	t.post(traderID, kind, amount, ref)
 	return nil
 }
+
+func (t *Trader) balance() float64 {
+	if trader, ok := t.Data.Traders[t.ID]; ok {
+		return trader.Account
+	}
+	return t.Account
+}
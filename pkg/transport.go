@@ -0,0 +1,148 @@
+package pkg
+
+import (
+	"errors"
+	"sync"
+)
+
+type Transport interface {
+	Register(id string, handler func(Message))
+	Unregister(id string)
+	Send(message Message) error
+	Flush()
+	Close()
+}
+
+type LocalTransport struct {
+	handlers map[string]func(Message)
+	queue    []Message
+}
+
+func NewLocalTransport() *LocalTransport {
+	return &LocalTransport{
+		handlers: make(map[string]func(Message)),
+		queue:    make([]Message, 0),
+	}
+}
+
+func (t *LocalTransport) Register(id string, handler func(Message)) {
+	t.handlers[id] = handler
+}
+
+func (t *LocalTransport) Unregister(id string) {
+	delete(t.handlers, id)
+}
+
+func (t *LocalTransport) Send(message Message) error {
+	if _, ok := t.handlers[message.To]; !ok {
+		return errors.New("unknown recipient")
+	}
+	t.queue = append(t.queue, message)
+	return nil
+}
+
+func (t *LocalTransport) Flush() {
+	for len(t.queue) > 0 {
+		message := t.queue[0]
+		t.queue = t.queue[1:]
+		if handler, ok := t.handlers[message.To]; ok {
+			handler(message)
+		}
+	}
+	t.queue = t.queue[:0]
+}
+
+func (t *LocalTransport) Close() {
+	t.handlers = make(map[string]func(Message))
+	t.queue = t.queue[:0]
+}
+
+type ChannelTransport struct {
+	locker  sync.RWMutex
+	inboxes map[string]chan Message
+	pending sync.WaitGroup
+}
+
+func NewChannelTransport() *ChannelTransport {
+	return &ChannelTransport{
+		inboxes: make(map[string]chan Message),
+	}
+}
+
+func (t *ChannelTransport) Register(id string, handler func(Message)) {
+	t.Unregister(id)
+
+	inbox, outbox := make(chan Message), make(chan Message)
+	go forward(inbox, outbox)
+	go func() {
+		for message := range outbox {
+			handler(message)
+			t.pending.Done()
+		}
+	}()
+
+	t.locker.Lock()
+	t.inboxes[id] = inbox
+	t.locker.Unlock()
+}
+
+func (t *ChannelTransport) Unregister(id string) {
+	t.locker.Lock()
+	defer t.locker.Unlock()
+	if inbox, ok := t.inboxes[id]; ok {
+		close(inbox)
+		delete(t.inboxes, id)
+	}
+}
+
+func (t *ChannelTransport) Send(message Message) error {
+	t.locker.RLock()
+	defer t.locker.RUnlock()
+	inbox, ok := t.inboxes[message.To]
+	if !ok {
+		return errors.New("unknown recipient")
+	}
+	t.pending.Add(1)
+	inbox <- message
+	return nil
+}
+
+func (t *ChannelTransport) Flush() {
+	t.pending.Wait()
+}
+
+func (t *ChannelTransport) Close() {
+	t.Flush()
+	t.locker.Lock()
+	defer t.locker.Unlock()
+	for id, inbox := range t.inboxes {
+		close(inbox)
+		delete(t.inboxes, id)
+	}
+}
+
+func forward(inbox <-chan Message, outbox chan<- Message) {
+	defer close(outbox)
+	queue := make([]Message, 0)
+	for inbox != nil || len(queue) > 0 {
+		if len(queue) == 0 {
+			message, ok := <-inbox
+			if !ok {
+				return
+			}
+			queue = append(queue, message)
+			continue
+		}
+
+		select {
+		case message, ok := <-inbox:
+			if !ok {
+				inbox = nil
+				continue
+			}
+			queue = append(queue, message)
+		case outbox <- queue[0]:
+			queue = queue[1:]
+		}
+	}
+}
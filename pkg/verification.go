@@ -1,56 +1,33 @@
 package pkg
 
 import (
-	"errors"
 	"slices"
 
-	"golang.org/x/exp/rand"
-
 	"github.com/Arka-Lab/LoR/tools"
 )
 
-const (
-	VerificationMin = 21
-	VerificationMax = 21
-	BanCount        = 3
-)
-
 func (t *Trader) SubmitRing(ring *FractalRing) error {
-	if err := t.validateFractalRing(ring); err != nil {
-		validErrors := []string{"invalid selected cooperation ring", "invalid verification team", "invalid cooperation ring coins"}
-		if !slices.Contains(validErrors, err.Error()) {
-			return err
-		}
-
-		if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
-			return nil
-		}
-		return err
-	}
-	return t.Vote()
+	return t.Data.Strategy.VerifyVote(t, ring, t.validateFractalRing(ring))
 }
 
-func (t *Trader) Vote() error {
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
-		return errors.New("bad behavior")
-	}
-	return nil
+func (t *Trader) Vote(fractal *FractalRing, ring CooperationTable) error {
+	return t.Data.Strategy.RoundVote(t, fractal, ring)
 }
 
-func selectRandomVerification(traders []string) (result []string) {
-	if len(traders) < VerificationMin {
+func selectRandomVerification(params *Params, random *tools.Random, traders []string) (result []string) {
+	if len(traders) < params.VerificationMin {
 		return nil
 	}
 
-	randomIndices := tools.RandomIndexes(len(traders), rand.Intn(min(VerificationMax, len(traders))-VerificationMin+1)+VerificationMin)
+	randomIndices := tools.RandomIndexes(random, len(traders), random.IntN(min(params.VerificationMax, len(traders))-params.VerificationMin+1)+params.VerificationMin)
 	for _, index := range randomIndices {
 		result = append(result, traders[index])
 	}
 	return
 }
 
-func selectVerificationTeam(traders []string, ring []string, firstOne string) (team []string) {
-	k := VerificationMin + tools.SHA256Int(ring)%(VerificationMax-VerificationMin+1)
+func selectVerificationTeam(params *Params, traders []string, ring []string, firstOne string, weights map[string]float64) (team []string) {
+	k := params.VerificationMin + tools.SHA256Int(ring)%(params.VerificationMax-params.VerificationMin+1)
 	if len(traders) < k {
 		return nil
 	}
@@ -60,21 +37,13 @@ func selectVerificationTeam(traders []string, ring []string, firstOne string) (t
 	slices.Sort(copiedTraders)
 
 	team = make([]string, k)
-	if firstOne != "" {
-		team[0] = firstOne
-		for i := 0; i < len(copiedTraders); i++ {
-			if copiedTraders[i] == firstOne {
-				copiedTraders[i] = copiedTraders[0]
-				copiedTraders = copiedTraders[1:]
-				break
-			}
+	team[0] = firstOne
+	for i := 0; i < len(copiedTraders); i++ {
+		if copiedTraders[i] == firstOne {
+			copiedTraders[i] = copiedTraders[0]
+			copiedTraders = copiedTraders[1:]
+			break
 		}
-	} else {
-		index := rand.Intn(len(traders))
-		team[0] = copiedTraders[index]
-
-		copiedTraders[index] = copiedTraders[0]
-		copiedTraders = copiedTraders[1:]
 	}
 
 	rnd := make([]int, 0)
@@ -83,6 +52,9 @@ func selectVerificationTeam(traders []string, ring []string, firstOne string) (t
 			rnd = tools.SHA256Arr(team)
 		}
 		index := rnd[0] % len(copiedTraders)
+		if weights != nil {
+			index = weightedIndex(copiedTraders, weights, rnd[0])
+		}
 		team[i], rnd = copiedTraders[index], rnd[1:]
 
 		copiedTraders[index] = copiedTraders[0]
@@ -90,3 +62,18 @@ func selectVerificationTeam(traders []string, ring []string, firstOne string) (t
 	}
 	return
 }
+
+func weightedIndex(traders []string, weights map[string]float64, seed int) int {
+	total := 0.
+	for _, traderID := range traders {
+		total += weights[traderID]
+	}
+
+	target := float64(seed%1000000) / 1000000 * total
+	for index, traderID := range traders {
+		if target -= weights[traderID]; target < 0 {
+			return index
+		}
+	}
+	return len(traders) - 1
+}
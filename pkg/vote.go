@@ -0,0 +1,370 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+const VerificationRound = -1
+
+type Vote struct {
+	Voter     string   `json:"voter"`
+	FractalID string   `json:"fractal_id"`
+	RingID    string   `json:"ring_id,omitempty"`
+	Round     int      `json:"round"`
+	Accept    bool     `json:"accept"`
+	Reason    string   `json:"reason,omitempty"`
+	Root      string   `json:"root"`
+	Proof     []string `json:"proof,omitempty"`
+	Signature string   `json:"signature"`
+}
+
+type Certificate struct {
+	FractalID string    `json:"fractal_id"`
+	RingID    string    `json:"ring_id,omitempty"`
+	Round     int       `json:"round"`
+	Accepted  bool      `json:"accepted"`
+	Quorum    float64   `json:"quorum,omitempty"`
+	Votes     []Vote    `json:"votes"`
+	Weights   []float64 `json:"weights,omitempty"`
+}
+
+type Equivocation struct {
+	First  Vote `json:"first"`
+	Second Vote `json:"second"`
+}
+
+func (v Vote) Digest() string {
+	return tools.SHA256Str(fmt.Sprintf("vote-%s-%s-%s-%d-%t-%s", v.Voter, v.FractalID, v.RingID, v.Round, v.Accept, v.Reason))
+}
+
+func (v Vote) Subject() string {
+	return fmt.Sprintf("%s-%s-%s-%d", v.Voter, v.FractalID, v.RingID, v.Round)
+}
+
+func (t *Trader) SignVotes(votes []Vote) error {
+	leaves := make([]string, len(votes))
+	for i := range votes {
+		votes[i].Voter = t.ID
+		leaves[i] = votes[i].Digest()
+	}
+
+	root := tools.MerkleRoot(leaves)
+	signature, err := tools.SignWithPrivateKeyStr(t.Data.Random, root, t.Data.PrivateKey, t.Data.Params.IDEncoding)
+	if err != nil {
+		return err
+	}
+	for i, proof := range tools.MerkleProofs(leaves) {
+		votes[i].Root, votes[i].Proof, votes[i].Signature = root, proof, signature
+	}
+	return nil
+}
+
+func VerifyVotes(votes []Vote, publicKey func(traderID string) *tools.PublicKey) error {
+	verified := make(map[string]bool)
+	for _, vote := range votes {
+		if !tools.VerifyMerkleProof(vote.Digest(), vote.Proof, vote.Root) {
+			return errors.New("invalid vote proof")
+		} else if verified[vote.Voter+vote.Root+vote.Signature] {
+			continue
+		}
+
+		key := publicKey(vote.Voter)
+		if key == nil {
+			return errors.New("voter not found")
+		} else if err := tools.VerifyWithPublicKeyStr(vote.Root, vote.Signature, key); err != nil {
+			return errors.New("invalid vote signature")
+		}
+		verified[vote.Voter+vote.Root+vote.Signature] = true
+	}
+	return nil
+}
+
+func NewCertificate(fractalID, ringID string, round int, team []string, votes []Vote, quorum float64, weights []float64) Certificate {
+	certificate := Certificate{FractalID: fractalID, RingID: ringID, Round: round, Quorum: quorum, Votes: votes, Weights: weights}
+	certificate.Accepted = certificate.tally(team)
+	return certificate
+}
+
+func VoteWeights(team []string, weigh func(traderID string) float64) []float64 {
+	if weigh == nil {
+		return nil
+	}
+	weights := make([]float64, len(team))
+	for i, traderID := range team {
+		weights[i] = weigh(traderID)
+	}
+	return weights
+}
+
+func (c Certificate) tally(team []string) bool {
+	if len(c.Votes) == 0 {
+		return false
+	}
+	accepts := make(map[string]bool, len(c.Votes))
+	for _, vote := range c.Votes {
+		accepts[vote.Voter] = vote.Accept
+	}
+
+	accepted, total := 0., 0.
+	for i, traderID := range team {
+		weight := 1.
+		if c.Weights != nil {
+			weight = c.Weights[i]
+		}
+		total += weight
+		if accepts[traderID] {
+			accepted += weight
+		}
+	}
+	if total == 0 {
+		return c.CountAccepted(team)
+	}
+
+	quorum := c.Quorum
+	if quorum == 0 {
+		quorum = MajorityQuorum
+	}
+	return accepted >= quorum*total
+}
+
+func (c Certificate) CountAccepted(team []string) bool {
+	accepted := 0
+	for _, vote := range c.Votes {
+		if vote.Accept {
+			accepted++
+		}
+	}
+	return len(c.Votes) > 0 && len(team)-accepted <= accepted
+}
+
+func (c Certificate) Verify(team []string, weights []float64, publicKey func(traderID string) *tools.PublicKey) error {
+	members := make(map[string]bool, len(team))
+	for _, traderID := range team {
+		members[traderID] = true
+	}
+
+	if len(c.Votes) == 0 {
+		return errors.New("certificate has no votes")
+	} else if !slices.Equal(c.Weights, weights) {
+		return errors.New("certificate weights do not match view")
+	}
+
+	voted := make(map[string]bool, len(c.Votes))
+	for _, vote := range c.Votes {
+		if vote.FractalID != c.FractalID || vote.RingID != c.RingID || vote.Round != c.Round {
+			return errors.New("vote does not match certificate")
+		} else if !members[vote.Voter] {
+			return errors.New("voter is not in verification team")
+		} else if voted[vote.Voter] {
+			return errors.New("duplicate vote in certificate")
+		}
+		voted[vote.Voter] = true
+	}
+
+	if err := VerifyVotes(c.Votes, publicKey); err != nil {
+		return err
+	} else if c.tally(team) != c.Accepted {
+		return errors.New("certificate decision does not match votes")
+	}
+	return nil
+}
+
+func (c Certificate) digest(team []string, weights []float64) string {
+	data, _ := json.Marshal(struct {
+		Certificate Certificate `json:"certificate"`
+		Team        []string    `json:"team"`
+		Weights     []float64   `json:"weights"`
+	}{c, team, weights})
+	return tools.SHA256Str(string(data))
+}
+
+func (t *Trader) VerifyCertificate(certificate Certificate, team []string, weights []float64) error {
+	digest := certificate.digest(team, weights)
+	if t.Data.verified[certificate.FractalID][digest] {
+		return nil
+	}
+	if err := certificate.Verify(team, weights, func(traderID string) *tools.PublicKey {
+		if trader, ok := t.Data.Traders[traderID]; ok {
+			return trader.PublicKey
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if t.Data.verified == nil {
+		t.Data.verified = make(map[string]map[string]bool)
+	}
+	if t.Data.verified[certificate.FractalID] == nil {
+		t.Data.verified[certificate.FractalID] = make(map[string]bool)
+	}
+	t.Data.verified[certificate.FractalID][digest] = true
+	return nil
+}
+
+func (c Certificate) Minority() []string {
+	var minority []string
+	for _, vote := range c.Votes {
+		if vote.Accept != c.Accepted {
+			minority = append(minority, vote.Voter)
+		}
+	}
+	return minority
+}
+
+func (c Certificate) subject() string {
+	return fmt.Sprintf("%s-%s-%d", c.FractalID, c.RingID, c.Round)
+}
+
+func (t *Trader) verifyDecision(certificate *Certificate, fractal *FractalRing) error {
+	if certificate == nil {
+		return errors.New("missing certificate")
+	}
+	team, weights := t.Data.Teams[certificate.FractalID], t.Data.Weights[certificate.FractalID]
+	if certificate.Round == VerificationRound {
+		if fractal == nil || fractal.ID != certificate.FractalID {
+			return errors.New("certificate does not match fractal ring")
+		} else if err := t.checkFractalRing(fractal); err != nil {
+			return err
+		}
+		team, weights = fractal.VerificationTeam, VoteWeights(fractal.VerificationTeam, t.VoteWeigher())
+	} else if team == nil {
+		return errors.New("fractal ring not found")
+	}
+	return t.VerifyCertificate(*certificate, team, weights)
+}
+
+func (f FractalRing) Weights() []float64 {
+	for _, certificate := range f.Certificates {
+		if certificate.Round == VerificationRound {
+			return certificate.Weights
+		}
+	}
+	return nil
+}
+
+func (t *Trader) verifyAcceptance(fractal FractalRing) ([]float64, error) {
+	weights := VoteWeights(fractal.VerificationTeam, t.VoteWeigher())
+	for _, certificate := range fractal.Certificates {
+		if certificate.Round != VerificationRound {
+			continue
+		} else if certificate.FractalID != fractal.ID {
+			return nil, errors.New("certificate does not match fractal ring")
+		} else if err := t.VerifyCertificate(certificate, fractal.VerificationTeam, weights); err != nil {
+			return nil, err
+		} else if !certificate.Accepted {
+			return nil, errors.New("fractal ring was not accepted")
+		}
+		return weights, nil
+	}
+	return nil, errors.New("missing verification certificate")
+}
+
+func (t *Trader) verifyPayout(ring CooperationTable, paid bool, certificate *Certificate, payments []Payment) error {
+	if certificate != nil {
+		if err := t.verifySettlement(ring, *certificate, paid); err != nil {
+			return err
+		}
+	} else if paid || !slices.ContainsFunc(ring.CoinIDs, t.ownerLeft) {
+		return errors.New("missing payout certificate")
+	}
+
+	paidCoins := make(map[string]bool, len(payments))
+	for _, payment := range payments {
+		coin, ok := t.Data.Coins[payment.Coin]
+		if !ok || coin.Owner != payment.Owner || !slices.Contains(ring.CoinIDs, payment.Coin) || paidCoins[payment.Coin] {
+			return errors.New("payment does not match cooperation ring")
+		}
+		paidCoins[payment.Coin] = true
+	}
+	return nil
+}
+
+func (t *Trader) ownerLeft(coinID string) bool {
+	coin, ok := t.Data.Coins[coinID]
+	if !ok {
+		return true
+	}
+	_, ok = t.Data.Traders[coin.Owner]
+	return !ok
+}
+
+func (t *Trader) verifySettlement(ring CooperationTable, certificate Certificate, accepted bool) error {
+	team, ok := t.Data.Teams[ring.FractalID]
+	if !ok {
+		return errors.New("fractal ring not found")
+	} else if certificate.FractalID != ring.FractalID || certificate.RingID != ring.ID {
+		return errors.New("certificate does not match cooperation ring")
+	} else if err := t.VerifyCertificate(certificate, team, t.Data.Weights[ring.FractalID]); err != nil {
+		return err
+	} else if certificate.Accepted != accepted {
+		if accepted {
+			return errors.New("cooperation ring was not accepted")
+		}
+		return errors.New("cooperation ring was not rejected")
+	} else if accepted && certificate.Round != t.Data.Params.RoundsCount-1 {
+		return errors.New("certificate is not from the last round")
+	}
+	return nil
+}
+
+func FindEquivocations(votes []Vote) []Equivocation {
+	var equivocations []Equivocation
+	first := make(map[string]Vote)
+	for _, vote := range votes {
+		if previous, ok := first[vote.Subject()]; !ok {
+			first[vote.Subject()] = vote
+		} else if previous.Accept != vote.Accept {
+			equivocations = append(equivocations, Equivocation{First: previous, Second: vote})
+		}
+	}
+	return equivocations
+}
+
+func (e Equivocation) Verify(publicKey *tools.PublicKey) error {
+	if e.First.Subject() != e.Second.Subject() {
+		return errors.New("votes are on different subjects")
+	} else if e.First.Accept == e.Second.Accept {
+		return errors.New("votes have the same decision")
+	}
+	return VerifyVotes([]Vote{e.First, e.Second}, func(string) *tools.PublicKey {
+		return publicKey
+	})
+}
+
+func VoteWeigher(params *Params, account func(traderID string) float64, coins map[string]CoinTable) func(traderID string) float64 {
+	switch params.VotingRule {
+	case AccountVotes:
+		return func(traderID string) float64 {
+			return max(account(traderID), 0)
+		}
+	case StakeVotes:
+		var blocked []string
+		for coinID, coin := range coins {
+			if coin.Status == Blocked {
+				blocked = append(blocked, coinID)
+			}
+		}
+		slices.Sort(blocked)
+
+		stakes := make(map[string]float64)
+		for _, coinID := range blocked {
+			stakes[coins[coinID].Owner] += coins[coinID].Amount
+		}
+		return func(traderID string) float64 {
+			return stakes[traderID]
+		}
+	}
+	return nil
+}
+
+func (t *Trader) VoteWeigher() func(traderID string) float64 {
+	return VoteWeigher(t.Data.Params, func(traderID string) float64 {
+		return t.Data.Traders[traderID].Account
+	}, t.Data.Coins)
+}
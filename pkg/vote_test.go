@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func TestCertificateCountsMissingVotes(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 4)
+	team := make([]string, len(traders))
+	for i, trader := range traders {
+		team[i] = trader.ID
+	}
+	verifier := traders[0]
+
+	certificate := signTestCertificate(t, traders[:1], team, "fractal", "", VerificationRound, true)
+	if err := verifier.VerifyCertificate(*certificate, team, nil); err != nil {
+		t.Fatal(err)
+	} else if certificate.Accepted {
+		t.Fatal("expected one accept out of four members to be rejected")
+	}
+
+	certificate = signTestCertificate(t, traders[:2], team, "fractal", "", VerificationRound, true)
+	if !certificate.Accepted {
+		t.Fatal("expected two accepts out of four members to be accepted")
+	}
+	certificate.Accepted = false
+	expectError(t, verifier.VerifyCertificate(*certificate, team, nil), "certificate decision does not match votes")
+
+	dropped := signTestCertificate(t, traders[:1], team, "fractal", "", VerificationRound, true)
+	dropped.Accepted = true
+	expectError(t, verifier.VerifyCertificate(*dropped, team, nil), "certificate decision does not match votes")
+
+	empty := NewCertificate("fractal", "", VerificationRound, team, nil, 0, nil)
+	if empty.Accepted || empty.CountAccepted(team) {
+		t.Fatal("expected a certificate without votes to be rejected")
+	}
+	empty.Accepted = true
+	expectError(t, verifier.VerifyCertificate(empty, team, nil), "certificate has no votes")
+}
+
+func TestVerifyCertificateChecksEachCertificateOnce(t *testing.T) {
+	traders := newTestTraders(t, tools.Ed25519, 3)
+	team := []string{traders[0].ID, traders[1].ID, traders[2].ID}
+	verifier := traders[0]
+
+	certificate := signTestCertificate(t, traders, team, "fractal", "ring", 0, true)
+	if err := verifier.VerifyCertificate(*certificate, team, nil); err != nil {
+		t.Fatal(err)
+	}
+	voter := verifier.Data.Traders[traders[1].ID]
+	delete(verifier.Data.Traders, voter.ID)
+	if err := verifier.VerifyCertificate(*certificate, team, nil); err != nil {
+		t.Fatalf("expected the verified certificate to be cached, got %v", err)
+	}
+
+	tampered := *certificate
+	tampered.Votes = slices.Clone(certificate.Votes)
+	tampered.Votes[2].Signature = tampered.Votes[1].Signature
+	if err := verifier.VerifyCertificate(tampered, team, nil); err == nil {
+		t.Fatal("expected a changed certificate to be verified again")
+	} else if err := verifier.VerifyCertificate(*certificate, team[:2], nil); err == nil {
+		t.Fatal("expected the certificate to be verified again for another team")
+	}
+
+	verifier.Data.Traders[voter.ID] = voter
+	verifier.RemoveFractalRing("fractal")
+	if len(verifier.Data.verified) != 0 {
+		t.Fatal("expected removing the fractal ring to drop its verified certificates")
+	}
+}
+
+func TestCertificateWeightsComeFromTheView(t *testing.T) {
+	params := DefaultParams()
+	params.SignatureScheme, params.VotingRule = tools.Ed25519, AccountVotes
+	traders := newTestTradersWith(t, params, 4)
+	team := make([]string, len(traders))
+	for i, trader := range traders {
+		team[i] = trader.ID
+	}
+	verifier := traders[1]
+	weights := VoteWeights(team, verifier.VoteWeigher())
+
+	honest := signTestCertificate(t, traders[:1], team, "fractal", "", VerificationRound, true)
+	*honest = NewCertificate("fractal", "", VerificationRound, team, honest.Votes, 0, weights)
+	if err := verifier.VerifyCertificate(*honest, team, weights); err != nil {
+		t.Fatal(err)
+	} else if honest.Accepted {
+		t.Fatal("expected one accept out of four equal weights to be rejected")
+	}
+
+	inflated := NewCertificate("fractal", "", VerificationRound, team, honest.Votes, 0, []float64{3000, 1000, 1000, 1000})
+	if !inflated.Accepted {
+		t.Fatal("expected the inflated weights to accept")
+	}
+	expectError(t, verifier.VerifyCertificate(inflated, team, weights), "certificate weights do not match view")
+
+	unweighted := NewCertificate("fractal", "", VerificationRound, team, honest.Votes, 0, nil)
+	expectError(t, verifier.VerifyCertificate(unweighted, team, weights), "certificate weights do not match view")
+}
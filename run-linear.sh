@@ -44,7 +44,7 @@ function run {
     else
         alpha=$(echo "$1/100" | bc -l)
         log "Running with alpha=$1%..."
-        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_traders -alpha=$alpha -save-to=$json_file > $result_file 2> $log_file
+        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_traders -alpha=$alpha -save-to=$json_file -checkpoint-dir=${json_file%.json}.checkpoints -checkpoint-interval=60000 > $result_file 2> $log_file && rm -rf ${json_file%.json}.checkpoints
         log "Run with alpha=$1% finished."
     fi
 
@@ -60,7 +60,7 @@ function run {
     else
         num_bad=$(echo "$1/100*$num_traders" | bc -l | awk '{print int($1)}')
         log "Running with $1% bad traders..."
-        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -save-to=$json_file -bad=$num_bad > $result_file 2> $log_file
+        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -save-to=$json_file -bad=$num_bad -checkpoint-dir=${json_file%.json}.checkpoints -checkpoint-interval=60000 > $result_file 2> $log_file && rm -rf ${json_file%.json}.checkpoints
         log "Run with $1% bad traders finished."
     fi
 
@@ -76,7 +76,7 @@ function run {
     else
         num_random=$(echo "$1/100*$num_traders" | bc -l | awk '{print int($1)}')
         log "Running with $1% random traders..."
-        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_random -save-to=$json_file > $result_file 2> $log_file
+        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_random -save-to=$json_file -checkpoint-dir=${json_file%.json}.checkpoints -checkpoint-interval=60000 > $result_file 2> $log_file && rm -rf ${json_file%.json}.checkpoints
         log "Run with $1% random traders finished."
     fi
 }
@@ -46,7 +46,7 @@ function run {
         log "Loaded from $json_file."
     else
         log "Running with $1% random traders and $2% bad traders..."
-        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_random -bad=$num_bad -save-to=$json_file > $result_file 2> $log_file
+        go run cmd/main.go -type=$num_types -time=$run_time -trader=$num_traders -random=$num_random -bad=$num_bad -save-to=$json_file -checkpoint-dir=${json_file%.json}.checkpoints -checkpoint-interval=60000 > $result_file 2> $log_file && rm -rf ${json_file%.json}.checkpoints
         log "Run with $1% random traders and $2% bad traders finished."
     fi
 }
@@ -1,5 +1,6 @@
 import os
 import sys
+import json
 
 def check_data(dir_path):
     data = {}
@@ -9,7 +10,11 @@ def check_data(dir_path):
             result = {}
             try:
                 with open(os.path.join(dir_path, file_name), 'r') as f:
-                    lines = f.readlines()
+                    content = f.read()
+                if content.lstrip().startswith('{'):
+                    result = json.loads(content)
+                else:
+                    lines = content.splitlines()
                     result['coins'] = int(lines[0].split(': ')[1])
                     result['fractals'] = int(lines[1].split(': ')[1])
                     result['run_coins'] = int(lines[2].split(': ')[1])
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+	"slices"
+
+	"filippo.io/edwards25519"
+)
+
+const ecvrfSuite = 0x03
+
+func decodePoint(data []byte) (*edwards25519.Point, error) {
+	p, err := new(edwards25519.Point).SetBytes(data)
+	if err != nil || !bytes.Equal(p.Bytes(), data) {
+		return nil, errors.New("invalid point")
+	}
+	return p, nil
+}
+
+func secretScalar(privateKey ed25519.PrivateKey) (*edwards25519.Scalar, []byte) {
+	hash := sha512.Sum512(privateKey.Seed())
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(hash[:32])
+	if err != nil {
+		panic(err)
+	}
+	return x, hash[32:]
+}
+
+func encodeToCurve(publicKey []byte, input string) (*edwards25519.Point, error) {
+	for counter := 0; counter < 256; counter++ {
+		hash := sha512.Sum512(slices.Concat([]byte{ecvrfSuite, 0x01}, publicKey, []byte(input), []byte{byte(counter), 0x00}))
+		if h, err := decodePoint(hash[:32]); err == nil {
+			return h.MultByCofactor(h), nil
+		}
+	}
+	return nil, errors.New("no curve point found")
+}
+
+func challenge(points ...*edwards25519.Point) []byte {
+	data := []byte{ecvrfSuite, 0x02}
+	for _, p := range points {
+		data = append(data, p.Bytes()...)
+	}
+	hash := sha512.Sum512(append(data, 0x00))
+	return hash[:16]
+}
+
+func challengeScalar(c []byte) *edwards25519.Scalar {
+	scalar, err := edwards25519.NewScalar().SetCanonicalBytes(slices.Concat(c, make([]byte, 16)))
+	if err != nil {
+		panic(err)
+	}
+	return scalar
+}
+
+func ecvrfHash(gamma *edwards25519.Point) []byte {
+	hash := sha512.Sum512(slices.Concat([]byte{ecvrfSuite, 0x03}, new(edwards25519.Point).MultByCofactor(gamma).Bytes(), []byte{0x00}))
+	return hash[:]
+}
+
+func ecvrfOutput(gamma *edwards25519.Point) string {
+	return EncodeID(ecvrfHash(gamma)[:32], HexIDs)
+}
+
+func ecvrfProve(privateKey ed25519.PrivateKey, input string) (string, []byte, error) {
+	x, prefix := secretScalar(privateKey)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	y, err := decodePoint(publicKey)
+	if err != nil {
+		return "", nil, errors.New("invalid public key")
+	}
+	h, err := encodeToCurve(publicKey, input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gamma := new(edwards25519.Point).ScalarMult(x, h)
+	nonce := sha512.Sum512(slices.Concat(prefix, h.Bytes()))
+	k, err := edwards25519.NewScalar().SetUniformBytes(nonce[:])
+	if err != nil {
+		return "", nil, err
+	}
+	c := challenge(y, h, gamma, new(edwards25519.Point).ScalarBaseMult(k), new(edwards25519.Point).ScalarMult(k, h))
+	s := edwards25519.NewScalar().MultiplyAdd(challengeScalar(c), x, k)
+	return ecvrfOutput(gamma), slices.Concat(gamma.Bytes(), c, s.Bytes()), nil
+}
+
+func ecvrfVerify(publicKey ed25519.PublicKey, input string, proof []byte) (string, error) {
+	if len(proof) != 80 {
+		return "", errors.New("invalid vrf proof length")
+	}
+	y, err := decodePoint(publicKey)
+	if err != nil || new(edwards25519.Point).MultByCofactor(y).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return "", errors.New("invalid public key")
+	}
+	gamma, err := decodePoint(proof[:32])
+	if err != nil {
+		return "", errors.New("invalid vrf proof")
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(proof[48:])
+	if err != nil {
+		return "", errors.New("invalid vrf proof")
+	}
+
+	h, err := encodeToCurve(publicKey, input)
+	if err != nil {
+		return "", err
+	}
+	c := edwards25519.NewScalar().Negate(challengeScalar(proof[32:48]))
+	u := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(c, y, s)
+	v := new(edwards25519.Point).VarTimeMultiScalarMult([]*edwards25519.Scalar{s, c}, []*edwards25519.Point{h, gamma})
+	if !bytes.Equal(challenge(y, h, gamma, u, v), proof[32:48]) {
+		return "", errors.New("invalid vrf proof")
+	}
+	return ecvrfOutput(gamma), nil
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+func TestECVRFVectors(t *testing.T) {
+	vectors := []struct {
+		secretKey, publicKey, alpha, proof, beta string
+	}{
+		{
+			"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+			"",
+			"8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805",
+			"90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae",
+		},
+		{
+			"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			"3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+			"72",
+			"f3141cd382dc42909d19ec5110469e4feae18300e94f304590abdced48aed5933bf0864a62558b3ed7f2fea45c92a465301b3bbf5e3e54ddf2d935be3b67926da3ef39226bbc355bdc9850112c8f4b02",
+			"eb4440665d3891d668e7e0fcaf587f1b4bd7fbfe99d0eb2211ccec90496310eb5e33821bc613efb94db5e5b54c70a848a0bef4553a41befc57663b56373a5031",
+		},
+		{
+			"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+			"fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025",
+			"af82",
+			"9bc0f79119cc5604bf02d23b4caede71393cedfbb191434dd016d30177ccbf8096bb474e53895c362d8628ee9f9ea3c0e52c7a5c691b6c18c9979866568add7a2d41b00b05081ed0f58ee5e31b3a970e",
+			"645427e5d00c62a23fb703732fa5d892940935942101e456ecca7bb217c61c452118fec1219202a0edcf038bb6373241578be7217ba85a2687f7a0310b2df19f",
+		},
+	}
+
+	for _, vector := range vectors {
+		seed, _ := hex.DecodeString(vector.secretKey)
+		alpha, _ := hex.DecodeString(vector.alpha)
+		privateKey := ed25519.NewKeyFromSeed(seed)
+		publicKey := privateKey.Public().(ed25519.PublicKey)
+		if encoded := hex.EncodeToString(publicKey); encoded != vector.publicKey {
+			t.Fatalf("expected public key %s, got %s", vector.publicKey, encoded)
+		}
+
+		output, proof, err := ecvrfProve(privateKey, string(alpha))
+		if err != nil {
+			t.Fatal(err)
+		} else if encoded := hex.EncodeToString(proof); encoded != vector.proof {
+			t.Fatalf("expected proof %s, got %s", vector.proof, encoded)
+		} else if output != vector.beta[:64] {
+			t.Fatalf("expected output %s, got %s", vector.beta[:64], output)
+		}
+
+		gamma, err := decodePoint(proof[:32])
+		if err != nil {
+			t.Fatal(err)
+		} else if beta := hex.EncodeToString(ecvrfHash(gamma)); beta != vector.beta {
+			t.Fatalf("expected beta %s, got %s", vector.beta, beta)
+		} else if verified, err := ecvrfVerify(publicKey, string(alpha), proof); err != nil || verified != output {
+			t.Fatalf("expected the proof to verify with output %s, got %s (%v)", output, verified, err)
+		}
+	}
+}
+
+func TestECVRFRejectsTamperedProofs(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(NewRandom(3), Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GeneratePrivateKey(NewRandom(4), Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, proof, err := ecvrfProve(privateKey.Ed25519, "selection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := privateKey.Public().Ed25519
+
+	for i := range proof {
+		tampered := append([]byte{}, proof...)
+		tampered[i] ^= 0x01
+		if _, err := ecvrfVerify(publicKey, "selection", tampered); err == nil {
+			t.Fatalf("expected a proof tampered at byte %d to be rejected", i)
+		}
+	}
+	if _, err := ecvrfVerify(publicKey, "other", proof); err == nil {
+		t.Fatal("expected the proof to fail for another input")
+	} else if _, err := ecvrfVerify(other.Public().Ed25519, "selection", proof); err == nil {
+		t.Fatal("expected the proof to fail under another key")
+	} else if _, err := ecvrfVerify(publicKey, "selection", proof[:79]); err == nil {
+		t.Fatal("expected a truncated proof to be rejected")
+	}
+}
@@ -0,0 +1,58 @@
+package tools
+
+func hashPair(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return SHA256Str(a + b)
+}
+
+func nextLevel(level []string) []string {
+	next := make([]string, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			next = append(next, hashPair(level[i], level[i+1]))
+		} else {
+			next = append(next, level[i])
+		}
+	}
+	return next
+}
+
+func MerkleRoot(leaves []string) string {
+	if len(leaves) == 0 {
+		return ""
+	}
+	level := leaves
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+func MerkleProofs(leaves []string) [][]string {
+	proofs := make([][]string, len(leaves))
+	positions := make([]int, len(leaves))
+	for i := range positions {
+		positions[i] = i
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		for i, position := range positions {
+			if sibling := position ^ 1; sibling < len(level) {
+				proofs[i] = append(proofs[i], level[sibling])
+			}
+			positions[i] = position / 2
+		}
+		level = nextLevel(level)
+	}
+	return proofs
+}
+
+func VerifyMerkleProof(leaf string, proof []string, root string) bool {
+	for _, sibling := range proof {
+		leaf = hashPair(leaf, sibling)
+	}
+	return leaf == root
+}
@@ -1,16 +1,51 @@
 package tools
 
 import (
-	"crypto"
-	crand "crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
-	"math/rand"
+	"errors"
+	"io"
+	"math/big"
+	"math/rand/v2"
 )
 
-func RandomIndexes(n, k int) (result []int) {
+type Random struct {
+	*rand.Rand
+	source *rand.PCG
+}
+
+func NewRandom(seed uint64) *Random {
+	source := rand.NewPCG(seed, seed)
+	return &Random{
+		Rand:   rand.New(source),
+		source: source,
+	}
+}
+
+func (r *Random) Fork() *Random {
+	return NewRandom(r.Uint64())
+}
+
+func (r *Random) MarshalBinary() ([]byte, error) {
+	return r.source.MarshalBinary()
+}
+
+func (r *Random) UnmarshalBinary(data []byte) error {
+	return r.source.UnmarshalBinary(data)
+}
+
+func (r *Random) Read(data []byte) (int, error) {
+	for i := 0; i < len(data); i += 8 {
+		value := r.Uint64()
+		for j := i; j < i+8 && j < len(data); j++ {
+			data[j], value = byte(value), value>>8
+		}
+	}
+	return len(data), nil
+}
+
+func RandomIndexes(random *Random, n, k int) (result []int) {
 	rnd := make([]int, 0)
-	result = append(result, rand.Intn(n))
+	result = append(result, random.IntN(n))
 	for i := 1; i < k; i++ {
 		if len(rnd) == 0 {
 			rnd = SHA256Arr(result)
@@ -20,35 +55,63 @@ func RandomIndexes(n, k int) (result []int) {
 	return
 }
 
-func GeneratePrivateKey(size int) (*rsa.PrivateKey, error) {
-	privateKey, err := rsa.GenerateKey(crand.Reader, size)
-	if err != nil {
-		return nil, err
+func generateDeterministicKey(random io.Reader, size int) (*rsa.PrivateKey, error) {
+	if size < 64 {
+		return nil, errors.New("key size too small")
 	}
-	if err := privateKey.Validate(); err != nil {
-		return nil, err
-	}
-	return privateKey, nil
-}
 
-func SignWithPrivateKey(data []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
-	hashed := sha256.Sum256(data)
-	return rsa.SignPSS(crand.Reader, privateKey, crypto.SHA256, hashed[:], nil)
-}
+	one, e := big.NewInt(1), big.NewInt(65537)
+	for {
+		p, err := generatePrime(random, size/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := generatePrime(random, size-size/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
 
-func VerifyWithPublicKey(data []byte, signature []byte, publicKey *rsa.PublicKey) error {
-	hashed := sha256.Sum256(data)
-	return rsa.VerifyPSS(publicKey, crypto.SHA256, hashed[:], signature, nil)
-}
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != size {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
 
-func SignWithPrivateKeyStr(data string, privateKey *rsa.PrivateKey) (string, error) {
-	signature, err := SignWithPrivateKey([]byte(data), privateKey)
-	if err != nil {
-		return "", err
+		privateKey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		privateKey.Precompute()
+		return privateKey, nil
 	}
-	return string(signature), nil
 }
 
-func VerifyWithPublicKeyStr(data string, signature string, publicKey *rsa.PublicKey) error {
-	return VerifyWithPublicKey([]byte(data), []byte(signature), publicKey)
+func generatePrime(random io.Reader, bits int) (*big.Int, error) {
+	data := make([]byte, (bits+7)/8)
+	topBits := uint(bits % 8)
+	if topBits == 0 {
+		topBits = 8
+	}
+
+	for {
+		if _, err := io.ReadFull(random, data); err != nil {
+			return nil, err
+		}
+		data[0] &= byte(1<<topBits - 1)
+		prime := new(big.Int).SetBytes(data)
+		prime.SetBit(prime, bits-1, 1)
+		prime.SetBit(prime, bits-2, 1)
+		prime.SetBit(prime, 0, 1)
+		if prime.ProbablyPrime(20) {
+			return prime, nil
+		}
+	}
 }
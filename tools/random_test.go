@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeterministicRSAKeys(t *testing.T) {
+	first, err := GeneratePrivateKey(NewRandom(7), RSAPSS, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GeneratePrivateKey(NewRandom(7), RSAPSS, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GeneratePrivateKey(NewRandom(8), RSAPSS, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !first.Public().Equal(second.Public()) {
+		t.Fatal("expected the same key from the same seed")
+	} else if first.Public().Equal(other.Public()) {
+		t.Fatal("expected different keys from different seeds")
+	} else if bits := first.RSA.N.BitLen(); bits != 1024 {
+		t.Fatalf("expected a 1024-bit modulus, got %d bits", bits)
+	} else if err := first.RSA.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("coin")
+	signature, err := SignWithPrivateKey(NewRandom(1), message, first)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := VerifyWithPublicKey(message, signature, second.Public()); err != nil {
+		t.Fatal(err)
+	} else if err := VerifyWithPublicKey(message, signature, other.Public()); err == nil {
+		t.Fatal("expected the signature to fail under another key")
+	}
+
+	der, err := first.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	} else if marshaled, _ := parsed.Marshal(); !bytes.Equal(der, marshaled) {
+		t.Fatal("expected the key to survive PKCS #8 encoding")
+	}
+}
+
+func TestDeterministicKeyRejectsSmallSizes(t *testing.T) {
+	if _, err := GeneratePrivateKey(NewRandom(1), RSAPSS, 32); err == nil {
+		t.Fatal("expected an error for a 32-bit key")
+	}
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	crand "crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+)
+
+type SignatureScheme string
+
+const (
+	RSAPSS  SignatureScheme = "rsa-pss"
+	Ed25519 SignatureScheme = "ed25519"
+)
+
+type IDEncoding string
+
+const (
+	HexIDs    IDEncoding = "hex"
+	Base64IDs IDEncoding = "base64"
+)
+
+const saltSize = 16
+
+type PrivateKey struct {
+	RSA     *rsa.PrivateKey
+	Ed25519 ed25519.PrivateKey
+}
+
+type PublicKey struct {
+	RSA     *rsa.PublicKey
+	Ed25519 ed25519.PublicKey
+}
+
+func GeneratePrivateKey(random io.Reader, scheme SignatureScheme, size int) (*PrivateKey, error) {
+	switch scheme {
+	case RSAPSS:
+		var privateKey *rsa.PrivateKey
+		var err error
+		if random == nil {
+			privateKey, err = rsa.GenerateKey(crand.Reader, size)
+		} else {
+			privateKey, err = generateDeterministicKey(random, size)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := privateKey.Validate(); err != nil {
+			return nil, err
+		}
+		return &PrivateKey{RSA: privateKey}, nil
+	case Ed25519:
+		if random == nil {
+			random = crand.Reader
+		}
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return nil, err
+		}
+		return &PrivateKey{Ed25519: ed25519.NewKeyFromSeed(seed)}, nil
+	}
+	return nil, errors.New("unknown signature scheme")
+}
+
+func ParsePrivateKey(der []byte) (*PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &PrivateKey{RSA: key}, nil
+	case ed25519.PrivateKey:
+		return &PrivateKey{Ed25519: key}, nil
+	}
+	return nil, errors.New("unsupported private key")
+}
+
+func (k *PrivateKey) Marshal() ([]byte, error) {
+	if k.RSA != nil {
+		return x509.MarshalPKCS8PrivateKey(k.RSA)
+	}
+	return x509.MarshalPKCS8PrivateKey(k.Ed25519)
+}
+
+func (k *PrivateKey) Public() *PublicKey {
+	if k.RSA != nil {
+		return &PublicKey{RSA: &k.RSA.PublicKey}
+	}
+	return &PublicKey{Ed25519: k.Ed25519.Public().(ed25519.PublicKey)}
+}
+
+func (k *PublicKey) Scheme() SignatureScheme {
+	if k.RSA != nil {
+		return RSAPSS
+	}
+	return Ed25519
+}
+
+func (k *PublicKey) Equal(other *PublicKey) bool {
+	if k == nil || other == nil {
+		return k == other
+	} else if k.RSA != nil || other.RSA != nil {
+		return k.RSA != nil && k.RSA.Equal(other.RSA)
+	}
+	return k.Ed25519.Equal(other.Ed25519)
+}
+
+func (k PublicKey) MarshalJSON() ([]byte, error) {
+	if k.RSA != nil {
+		return json.Marshal(k.RSA)
+	}
+	return json.Marshal(struct {
+		Ed25519 []byte `json:"ed25519"`
+	}{k.Ed25519})
+}
+
+func (k *PublicKey) UnmarshalJSON(data []byte) error {
+	var key struct {
+		N       *big.Int
+		E       int
+		Ed25519 []byte `json:"ed25519"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+
+	switch {
+	case key.N != nil:
+		k.RSA, k.Ed25519 = &rsa.PublicKey{N: key.N, E: key.E}, nil
+	case len(key.Ed25519) == ed25519.PublicKeySize:
+		k.RSA, k.Ed25519 = nil, key.Ed25519
+	default:
+		return errors.New("invalid public key")
+	}
+	return nil
+}
+
+func SignWithPrivateKey(random io.Reader, data []byte, privateKey *PrivateKey) ([]byte, error) {
+	if random == nil {
+		random = crand.Reader
+	}
+	if privateKey == nil {
+		return nil, errors.New("missing private key")
+	} else if privateKey.RSA != nil {
+		hashed := sha256.Sum256(data)
+		return rsa.SignPSS(random, privateKey.RSA, crypto.SHA256, hashed[:], nil)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+	return append(salt, ed25519.Sign(privateKey.Ed25519, append(salt, data...))...), nil
+}
+
+func VerifyWithPublicKey(data []byte, signature []byte, publicKey *PublicKey) error {
+	if publicKey == nil {
+		return errors.New("missing public key")
+	} else if publicKey.RSA != nil {
+		hashed := sha256.Sum256(data)
+		return rsa.VerifyPSS(publicKey.RSA, crypto.SHA256, hashed[:], signature, nil)
+	}
+
+	if len(signature) != saltSize+ed25519.SignatureSize {
+		return errors.New("invalid signature length")
+	}
+	salt := signature[:saltSize]
+	if !ed25519.Verify(publicKey.Ed25519, append(append([]byte{}, salt...), data...), signature[saltSize:]) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func EncodeID(data []byte, encoding IDEncoding) string {
+	if encoding == Base64IDs {
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return hex.EncodeToString(data)
+}
+
+func DecodeID(id string) ([]byte, error) {
+	if data, err := hex.DecodeString(id); err == nil {
+		return data, nil
+	}
+	return base64.RawURLEncoding.DecodeString(id)
+}
+
+func SignWithPrivateKeyStr(random io.Reader, data string, privateKey *PrivateKey, encoding IDEncoding) (string, error) {
+	signature, err := SignWithPrivateKey(random, []byte(data), privateKey)
+	if err != nil {
+		return "", err
+	}
+	return EncodeID(signature, encoding), nil
+}
+
+func VerifyWithPublicKeyStr(data string, signature string, publicKey *PublicKey) error {
+	decoded, err := DecodeID(signature)
+	if err != nil {
+		return err
+	}
+	return VerifyWithPublicKey([]byte(data), decoded, publicKey)
+}
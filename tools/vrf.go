@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+func mgf1(seed []byte, length int) []byte {
+	var result []byte
+	var counter [4]byte
+	for i := uint32(0); len(result) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		hash := sha256.Sum256(append(append([]byte{}, seed...), counter[:]...))
+		result = append(result, hash[:]...)
+	}
+	return result[:length]
+}
+
+func vrfMessage(publicKey *rsa.PublicKey, input string) *big.Int {
+	size := (publicKey.N.BitLen() + 7) / 8
+	seed := append(publicKey.N.Bytes(), []byte(input)...)
+	return new(big.Int).SetBytes(mgf1(seed, size-1))
+}
+
+func vrfOutput(proof []byte) string {
+	hash := sha256.Sum256(append([]byte("vrf-output"), proof...))
+	return hex.EncodeToString(hash[:])
+}
+
+func VRFProve(privateKey *PrivateKey, input string) (output string, proof string, err error) {
+	if privateKey == nil {
+		return "", "", errors.New("missing private key")
+	} else if privateKey.Ed25519 != nil {
+		output, signature, err := ecvrfProve(privateKey.Ed25519, input)
+		return output, hex.EncodeToString(signature), err
+	}
+	message := vrfMessage(&privateKey.RSA.PublicKey, input)
+	signature := vrfSign(privateKey.RSA, message).FillBytes(make([]byte, (privateKey.RSA.N.BitLen()+7)/8))
+	return vrfOutput(signature), hex.EncodeToString(signature), nil
+}
+
+func vrfSign(privateKey *rsa.PrivateKey, message *big.Int) *big.Int {
+	precomputed := privateKey.Precomputed
+	if len(privateKey.Primes) != 2 || precomputed.Dp == nil || precomputed.Dq == nil || precomputed.Qinv == nil {
+		return new(big.Int).Exp(message, privateKey.D, privateKey.N)
+	}
+
+	p, q := privateKey.Primes[0], privateKey.Primes[1]
+	m1 := new(big.Int).Exp(message, precomputed.Dp, p)
+	m2 := new(big.Int).Exp(message, precomputed.Dq, q)
+	h := m1.Sub(m1, m2)
+	h.Mul(h, precomputed.Qinv).Mod(h, p)
+	return h.Mul(h, q).Add(h, m2)
+}
+
+func VRFVerify(key *PublicKey, input string, proof string) (string, error) {
+	if key == nil {
+		return "", errors.New("missing public key")
+	}
+	signature, err := hex.DecodeString(proof)
+	if err != nil {
+		return "", err
+	} else if key.Ed25519 != nil {
+		return ecvrfVerify(key.Ed25519, input, signature)
+	}
+
+	publicKey := key.RSA
+	if len(signature) != (publicKey.N.BitLen()+7)/8 {
+		return "", errors.New("invalid vrf proof length")
+	}
+
+	value := new(big.Int).SetBytes(signature)
+	if value.Cmp(publicKey.N) >= 0 {
+		return "", errors.New("invalid vrf proof")
+	}
+	exponent := big.NewInt(int64(publicKey.E))
+	if new(big.Int).Exp(value, exponent, publicKey.N).Cmp(vrfMessage(publicKey, input)) != 0 {
+		return "", errors.New("invalid vrf proof")
+	}
+	return vrfOutput(signature), nil
+}